@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Giankrp/nvimgotrack/internal/forge"
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/gitlocal"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// forgeHosts collects repeated -forge host=kind flags.
+type forgeHosts map[string]forge.Kind
+
+func (f forgeHosts) String() string {
+	var parts []string
+	for host, kind := range f {
+		parts = append(parts, host+"="+string(kind))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f forgeHosts) Set(s string) error {
+	host, kind, ok := strings.Cut(s, "=")
+	if !ok || host == "" {
+		return fmt.Errorf("want host=kind, got %q", s)
+	}
+	k, err := forge.ParseKind(kind)
+	if err != nil {
+		return err
+	}
+	f[strings.ToLower(host)] = k
+	return nil
+}
+
+// hostURLs collects repeated -api-url host=url flags.
+type hostURLs map[string]string
+
+func (h hostURLs) String() string {
+	var parts []string
+	for host, url := range h {
+		parts = append(parts, host+"="+url)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (h hostURLs) Set(s string) error {
+	host, url, ok := strings.Cut(s, "=")
+	if !ok || host == "" || url == "" {
+		return fmt.Errorf("want host=url, got %q", s)
+	}
+	h[strings.ToLower(host)] = url
+	return nil
+}
+
+// kindTokenEnv lists the environment variables checked for a host of each
+// kind when no explicit credential is configured.
+var kindTokenEnv = map[forge.Kind][]string{
+	forge.KindGitHub: {"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"},
+	forge.KindGitLab: {"GITLAB_TOKEN"},
+	forge.KindGitea:  {"GITEA_TOKEN"},
+}
+
+// credentialSource resolves API tokens per host.
+type credentialSource struct {
+	explicit github.Credentials // -token-file / env for github.com, -credentials file
+	ghHosts  github.Credentials // gh CLI hosts.yml, read lazily
+	loaded   bool
+}
+
+// newCredentialSource seeds github.com with githubToken and adds every
+// entry of credentialsFile, if set.
+func newCredentialSource(githubToken, credentialsFile string) (*credentialSource, error) {
+	cs := &credentialSource{explicit: github.Credentials{}}
+	if githubToken != "" {
+		cs.explicit[parser.DefaultHost] = githubToken
+	}
+	if credentialsFile != "" {
+		file, err := readCredentialsFile(credentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		cs.explicit.Merge(file)
+	}
+	return cs, nil
+}
+
+// token returns the token for host: explicit credentials first, then the
+// environment variables for its kind, then gh's hosts.yml for GitHub hosts.
+func (cs *credentialSource) token(host string, kind forge.Kind) string {
+	if t := cs.explicit.Token(host); t != "" {
+		return t
+	}
+	if host != parser.DefaultHost {
+		for _, env := range kindTokenEnv[kind] {
+			if t := os.Getenv(env); t != "" {
+				return t
+			}
+		}
+	}
+	if kind != forge.KindGitHub {
+		return ""
+	}
+	if !cs.loaded {
+		cs.loaded = true
+		cs.ghHosts, _ = github.LoadGHHosts(github.GHHostsPath())
+	}
+	return cs.ghHosts.Token(host)
+}
+
+// readCredentialsFile parses a file of "host token" lines. Blank lines and
+// lines starting with # are ignored.
+func readCredentialsFile(path string) (github.Credentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+	defer f.Close()
+
+	creds := github.Credentials{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"host token\"", path, n)
+		}
+		creds[strings.ToLower(fields[0])] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// buildForges creates the registry that routes each plugin host to a
+// client. The local backend serves every host from disk; otherwise each
+// well-known or -forge host gets an API client for its kind, with GitHub
+// hosts using the selected REST or GraphQL backend. GitHub clients report
+// rate limit waits to onPause.
+func buildForges(opts options, creds *credentialSource, plugins []parser.Plugin, onPause func(github.Pause)) (*forge.Registry, error) {
+	if opts.backend == "local" {
+		return forge.Single(gitlocal.New(opts.lazyDir, plugins, opts.fetch)), nil
+	}
+	if opts.backend != "rest" && opts.backend != "graphql" {
+		return nil, fmt.Errorf("invalid -backend %q: want rest, graphql or local", opts.backend)
+	}
+
+	hosts := make(map[string]forge.Kind)
+	for host, kind := range forge.KnownHosts {
+		hosts[host] = kind
+	}
+	for host, kind := range opts.forges {
+		hosts[host] = kind
+	}
+	for host := range opts.apiURLs {
+		if _, ok := hosts[host]; !ok {
+			return nil, fmt.Errorf("-api-url %s: unknown host; declare its kind with -forge %s=github|gitlab|gitea", host, host)
+		}
+	}
+
+	forges := forge.NewRegistry()
+	for host, kind := range hosts {
+		token := creds.token(host, kind)
+		baseURL := opts.apiURLs[host]
+		switch kind {
+		case forge.KindGitHub:
+			f, err := newGitHubForge(opts, host, baseURL, token, onPause)
+			if err != nil {
+				return nil, err
+			}
+			forges.Register(host, f)
+		case forge.KindGitLab:
+			if baseURL == "" {
+				forges.Register(host, forge.NewGitLab(host, token))
+			} else {
+				forges.Register(host, forge.NewGitLabWithBaseURL(baseURL, token))
+			}
+		case forge.KindGitea:
+			if baseURL == "" {
+				forges.Register(host, forge.NewGitea(host, token))
+			} else {
+				forges.Register(host, forge.NewGiteaWithBaseURL(baseURL, token))
+			}
+		}
+	}
+	return forges, nil
+}
+
+// newGitHubForge returns the REST or GraphQL client for a github.com or
+// GitHub Enterprise Server host.
+func newGitHubForge(opts options, host, baseURL, token string, onPause func(github.Pause)) (forge.Forge, error) {
+	if opts.backend == "graphql" {
+		if token == "" {
+			return nil, fmt.Errorf("the graphql backend requires a token for %s (GITHUB_TOKEN, GH_TOKEN, -token-file, -credentials or gh auth login)", host)
+		}
+		endpoint := github.GraphQLURL(host)
+		if baseURL != "" {
+			endpoint = github.GraphQLURLForAPI(baseURL)
+		}
+		return github.NewGraphQLClientWithOptions(github.GraphQLOptions{Endpoint: endpoint, Token: token, OnPause: onPause}), nil
+	}
+	if baseURL == "" {
+		baseURL = github.APIURL(host)
+	}
+	return github.NewClientWithOptions(github.Options{
+		Token:      token,
+		BaseURL:    baseURL,
+		NoCache:    opts.noCache,
+		Refresh:    opts.refresh,
+		MaxCommits: opts.maxCommits,
+		OnPause:    onPause,
+	}), nil
+}
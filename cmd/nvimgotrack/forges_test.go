@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Giankrp/nvimgotrack/internal/forge"
+)
+
+func TestCredentialSource(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GH_CONFIG_DIR", dir)
+	t.Setenv("GITLAB_TOKEN", "env-gitlab")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "")
+	t.Setenv("GITHUB_ENTERPRISE_TOKEN", "")
+
+	hosts := "ghe.example.com:\n    oauth_token: from-gh\n"
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(hosts), 0600); err != nil {
+		t.Fatal(err)
+	}
+	credsPath := filepath.Join(dir, "creds")
+	creds := "# per-host tokens\ngitlab.example.com  file-gitlab\n\ngithub.com file-github\n"
+	if err := os.WriteFile(credsPath, []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := newCredentialSource("flag-github", credsPath)
+	if err != nil {
+		t.Fatalf("newCredentialSource failed: %v", err)
+	}
+
+	tests := []struct {
+		host string
+		kind forge.Kind
+		want string
+	}{
+		{"github.com", forge.KindGitHub, "flag-github"},
+		{"gitlab.example.com", forge.KindGitLab, "file-gitlab"},
+		{"gitlab.com", forge.KindGitLab, "env-gitlab"},
+		{"ghe.example.com", forge.KindGitHub, "from-gh"},
+		{"codeberg.org", forge.KindGitea, ""},
+	}
+	for _, tt := range tests {
+		if got := cs.token(tt.host, tt.kind); got != tt.want {
+			t.Errorf("token(%s): got %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestReadCredentialsFileRejectsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "creds")
+	if err := os.WriteFile(path, []byte("github.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCredentialsFile(path); err == nil {
+		t.Error("expected error for a line without a token")
+	}
+}
+
+func TestBuildForgesRejectsUnknownAPIURLHost(t *testing.T) {
+	cs, err := newCredentialSource("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := options{backend: "rest", forges: forgeHosts{}, apiURLs: hostURLs{"ghe.example.com": "https://ghe.example.com/api/v3"}}
+	if _, err := buildForges(opts, cs, nil, nil); err == nil || !strings.Contains(err.Error(), "-forge ghe.example.com=") {
+		t.Errorf("buildForges error = %v, want a hint to declare the host with -forge", err)
+	}
+
+	opts.forges["ghe.example.com"] = forge.KindGitHub
+	if _, err := buildForges(opts, cs, nil, nil); err != nil {
+		t.Errorf("buildForges with -forge: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/detector"
+	"github.com/Giankrp/nvimgotrack/internal/forge"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+	"github.com/Giankrp/nvimgotrack/internal/report"
+)
+
+// Exit codes for headless mode. 1 and 2 are left to setup failures and flag
+// errors so a CI job can tell them apart from analysis results.
+const (
+	exitOK            = 0
+	exitFatal         = 1
+	exitUsage         = 2
+	exitFeature       = 3
+	exitDeprecation   = 4
+	exitBreaking      = 5
+	exitAnalysisError = 6
+)
+
+// parseFailOn maps a --fail-on value to the lowest severity that fails the run.
+func parseFailOn(s string) (detector.Severity, error) {
+	switch s {
+	case "feature":
+		return detector.SeverityFeature, nil
+	case "deprecation":
+		return detector.SeverityDeprecation, nil
+	case "breaking":
+		return detector.SeverityBreaking, nil
+	}
+	return 0, fmt.Errorf("invalid --fail-on %q: want feature, deprecation or breaking", s)
+}
+
+// headlessConfig controls how runHeadless reports its results.
+type headlessConfig struct {
+	out         io.Writer
+	format      string // "text" or "json"
+	lockfile    string
+	profiles    []parser.Profile
+	diagnostics []parser.Diagnostic
+	failOn      detector.Severity
+	workers     int
+	// pluginTimeout, if positive, bounds the analysis of each plugin.
+	pluginTimeout time.Duration
+	rules         *detector.Rules
+	local         detector.CommitSource
+}
+
+// runHeadless analyzes every plugin without the TUI, writes the results to
+// cfg.out and returns the process exit code. Plugins not analyzed before
+// ctx is done are reported with the cancellation as their error.
+func runHeadless(ctx context.Context, cfg headlessConfig, forges *forge.Registry, plugins []parser.Plugin) int {
+	reports := make([]detector.PluginReport, len(plugins))
+	opts := detector.Options{Workers: cfg.workers, PluginTimeout: cfg.pluginTimeout, Rules: cfg.rules, Local: cfg.local}
+	for ev := range detector.AnalyzeAllWithOptions(ctx, forges, plugins, opts) {
+		if !ev.Started {
+			reports[ev.Index] = ev.Report
+		}
+	}
+	detector.SortReports(reports)
+
+	if cfg.format == "json" {
+		doc := report.New(cfg.lockfile, reports)
+		doc.Profiles = cfg.profiles
+		doc.Diagnostics = cfg.diagnostics
+		if err := report.Write(cfg.out, doc); err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitFatal
+		}
+	} else {
+		printSummary(cfg.out, reports)
+	}
+	return exitCode(reports, cfg.failOn)
+}
+
+// printSummary writes one line per plugin that is behind or failed, followed
+// by the totals.
+func printSummary(w io.Writer, reports []detector.PluginReport) {
+	var counts [detector.SeverityBreaking + 1]int
+	var errors, unhealthy int
+	for _, r := range reports {
+		if r.Health.Status != detector.HealthOK {
+			unhealthy++
+		}
+		if r.Rewritten {
+			fmt.Fprintf(w, "⚠  %-32s %s\n", displayName(r.Plugin), r.HistoryNote())
+		}
+		if r.Error != "" {
+			errors++
+			fmt.Fprintf(w, "✗  %-32s %s\n", displayName(r.Plugin), r.Error)
+			continue
+		}
+		counts[r.Severity]++
+		if r.MovedTo != "" {
+			fmt.Fprintf(w, "↪  %-32s moved to %s (%s)\n", displayName(r.Plugin), r.MovedTo, r.SpecFix)
+		}
+		if r.Health.BranchMissing || r.Diverged() {
+			fmt.Fprintf(w, "⑂  %-32s %s\n", displayName(r.Plugin), r.BranchNote())
+		}
+		if r.Severity == detector.SeverityOK && r.Health.Status == detector.HealthOK {
+			continue
+		}
+		msg := firstMessage(r)
+		if r.Health.Status != detector.HealthOK {
+			msg = fmt.Sprintf("[%s] %s", r.Health.Status.Name(), msg)
+		}
+		if r.Partial {
+			msg = "[partial] " + msg
+		}
+		fmt.Fprintf(w, "%s %-32s +%-6d %s\n", r.Severity.Icon(), displayName(r.Plugin), r.BehindBy, msg)
+	}
+	fmt.Fprintf(w, "\n%d breaking, %d deprecated, %d with updates, %d up to date, %d errors, %d archived/deleted/stale (%d plugins)\n",
+		counts[detector.SeverityBreaking], counts[detector.SeverityDeprecation],
+		counts[detector.SeverityFeature], counts[detector.SeverityOK], errors, unhealthy, len(reports))
+}
+
+// displayName labels a plugin with the profiles locking it, if any.
+func displayName(p parser.Plugin) string {
+	if len(p.Profiles) == 0 {
+		return p.Name
+	}
+	return fmt.Sprintf("%s [%s]", p.Name, strings.Join(p.Profiles, ","))
+}
+
+// firstMessage returns the most relevant commit message for the summary line.
+func firstMessage(r detector.PluginReport) string {
+	switch {
+	case len(r.BreakingMsgs) > 0:
+		return truncate(r.BreakingMsgs[0].Message, 80)
+	case len(r.DeprecMsgs) > 0:
+		return truncate(r.DeprecMsgs[0].Message, 80)
+	}
+	return ""
+}
+
+// exitCode returns the code for the worst severity if it reaches failOn.
+// Analysis errors fail the run unless a higher-priority severity already did.
+func exitCode(reports []detector.PluginReport, failOn detector.Severity) int {
+	worst := detector.SeverityOK
+	var failed bool
+	for _, r := range reports {
+		if r.Error != "" {
+			failed = true
+			continue
+		}
+		if r.Severity > worst {
+			worst = r.Severity
+		}
+	}
+
+	if worst >= failOn {
+		switch worst {
+		case detector.SeverityBreaking:
+			return exitBreaking
+		case detector.SeverityDeprecation:
+			return exitDeprecation
+		case detector.SeverityFeature:
+			return exitFeature
+		}
+	}
+	if failed {
+		return exitAnalysisError
+	}
+	return exitOK
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) > maxLen {
+		return s[:maxLen-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Giankrp/nvimgotrack/internal/detector"
+)
+
+func TestExitCode(t *testing.T) {
+	ok := detector.PluginReport{Severity: detector.SeverityOK}
+	feature := detector.PluginReport{Severity: detector.SeverityFeature, BehindBy: 3}
+	deprec := detector.PluginReport{Severity: detector.SeverityDeprecation, BehindBy: 2}
+	breaking := detector.PluginReport{Severity: detector.SeverityBreaking, BehindBy: 7}
+	failed := detector.PluginReport{Error: "compare failed: not found"}
+
+	tests := []struct {
+		name    string
+		reports []detector.PluginReport
+		failOn  detector.Severity
+		want    int
+	}{
+		{"all ok", []detector.PluginReport{ok, ok}, detector.SeverityBreaking, exitOK},
+		{"feature below threshold", []detector.PluginReport{ok, feature}, detector.SeverityBreaking, exitOK},
+		{"feature threshold", []detector.PluginReport{ok, feature}, detector.SeverityFeature, exitFeature},
+		{"deprecation below threshold", []detector.PluginReport{deprec}, detector.SeverityBreaking, exitOK},
+		{"deprecation threshold", []detector.PluginReport{deprec, feature}, detector.SeverityDeprecation, exitDeprecation},
+		{"breaking wins", []detector.PluginReport{deprec, breaking}, detector.SeverityDeprecation, exitBreaking},
+		{"breaking beats errors", []detector.PluginReport{failed, breaking}, detector.SeverityBreaking, exitBreaking},
+		{"error", []detector.PluginReport{ok, failed}, detector.SeverityBreaking, exitAnalysisError},
+		{"error below threshold", []detector.PluginReport{deprec, failed}, detector.SeverityBreaking, exitAnalysisError},
+	}
+
+	for _, tt := range tests {
+		if got := exitCode(tt.reports, tt.failOn); got != tt.want {
+			t.Errorf("%s: exitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	if s, err := parseFailOn("deprecation"); err != nil || s != detector.SeverityDeprecation {
+		t.Errorf("parseFailOn(deprecation) = %v, %v", s, err)
+	}
+	if _, err := parseFailOn("sometimes"); err == nil {
+		t.Error("expected error for invalid --fail-on value")
+	}
+}
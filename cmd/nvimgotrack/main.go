@@ -0,0 +1,238 @@
+// Command nvimgotrack reports breaking changes in the plugins pinned by a
+// lazy.nvim lockfile.
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Giankrp/nvimgotrack/internal/detector"
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/gitlocal"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+	"github.com/Giankrp/nvimgotrack/internal/tui"
+)
+
+type options struct {
+	lockfile      string
+	configDir     string
+	noCache       bool
+	refresh       bool
+	tokenFile     string
+	headless      bool
+	failOn        string
+	format        string
+	output        string
+	workers       int
+	maxCommits    int
+	timeout       time.Duration
+	pluginTimeout time.Duration
+	rulesFile     string
+	backend       string
+	lazyDir       string
+	fetch         bool
+	forges        forgeHosts
+	apiURLs       hostURLs
+	credsFile     string
+	profile       string
+	all           bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) > 0 && args[0] == "rules" {
+		return runRules(args[1:], os.Stdin, os.Stdout)
+	}
+
+	opts := options{forges: forgeHosts{}, apiURLs: hostURLs{}}
+	fs := flag.NewFlagSet("nvimgotrack", flag.ContinueOnError)
+	fs.StringVar(&opts.lockfile, "lockfile", "", "path to the plugin lockfile: lazy-lock.json, packer snapshot or packer_compiled.lua, :PlugSnapshot output, rocks.toml or mini-deps-snap (default: search the Neovim config dir)")
+	fs.StringVar(&opts.configDir, "config", "", "Neovim config dir to scan for plugin specs (default: ~/.config/$NVIM_APPNAME)")
+	fs.StringVar(&opts.profile, "profile", "", "NVIM_APPNAME of the config to analyze (default: $NVIM_APPNAME or nvim)")
+	fs.BoolVar(&opts.all, "all-profiles", false, "analyze every config under the config home that has a lockfile, merged by plugin and commit")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "disable the on-disk response cache")
+	fs.BoolVar(&opts.refresh, "refresh", false, "revalidate every cached response instead of trusting it until it expires")
+	fs.StringVar(&opts.tokenFile, "token-file", "", "read the GitHub token from this file instead of GITHUB_TOKEN/GH_TOKEN")
+	fs.BoolVar(&opts.headless, "headless", false, "print a plain-text report instead of starting the TUI")
+	fs.StringVar(&opts.failOn, "fail-on", "breaking", "lowest severity that fails a headless run: feature, deprecation or breaking")
+	fs.StringVar(&opts.backend, "backend", "rest", "data backend: rest, graphql (batched, requires a token) or local (read lazy.nvim's plugin checkouts)")
+	fs.StringVar(&opts.lazyDir, "lazy-dir", "", "where lazy.nvim clones plugins, read by -backend=local and to date commits removed upstream (default: ~/.local/share/$NVIM_APPNAME/lazy)")
+	fs.BoolVar(&opts.fetch, "fetch", false, "run git fetch in each checkout before analyzing, for -backend=local")
+	fs.Var(opts.forges, "forge", "API flavor for a self-hosted forge, as host=github (Enterprise Server), host=gitlab or host=gitea (repeatable)")
+	fs.Var(opts.apiURLs, "api-url", "override a host's API base URL, as host=url (repeatable)")
+	fs.StringVar(&opts.credsFile, "credentials", "", "file of \"host token\" lines with per-host API tokens")
+	fs.IntVar(&opts.workers, "concurrency", 4, "number of plugins analyzed in parallel")
+	fs.IntVar(&opts.maxCommits, "max-commits", github.DefaultMaxCommits, "most commits fetched per plugin compare, for -backend=rest; reports past it are marked partial")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "give up on the whole analysis after this long, e.g. 2m (default: no limit)")
+	fs.DurationVar(&opts.pluginTimeout, "plugin-timeout", 0, "give up on a single plugin after this long, e.g. 30s (default: no limit)")
+	fs.StringVar(&opts.rulesFile, "rules", "", "JSON file of extra detection rules and exclusions; check it with \"nvimgotrack rules test\"")
+	fs.StringVar(&opts.format, "format", "text", "headless output format: text or json")
+	fs.StringVar(&opts.output, "o", "", "write the headless report to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if opts.format != "text" && opts.format != "json" {
+		fmt.Fprintf(os.Stderr, "nvimgotrack: invalid -format %q: want text or json\n", opts.format)
+		return exitUsage
+	}
+
+	failOn, err := parseFailOn(opts.failOn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+		return exitUsage
+	}
+
+	appName := cmp.Or(opts.profile, parser.AppName())
+
+	var (
+		lockPath string
+		profiles []parser.Profile
+		plugins  []parser.Plugin
+		diags    []parser.Diagnostic
+	)
+	if opts.all {
+		if opts.lockfile != "" || opts.configDir != "" || opts.profile != "" {
+			fmt.Fprintln(os.Stderr, "nvimgotrack: -all-profiles cannot be combined with -lockfile, -config or -profile")
+			return exitUsage
+		}
+		profiles, err = parser.FindProfiles()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitFatal
+		}
+		plugins, diags, err = parser.ParseProfiles(profiles)
+	} else {
+		lockPath, err = parser.FindLockFile(opts.lockfile, appName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitFatal
+		}
+		plugins, diags, err = parser.ParseProfile(parser.Profile{Name: appName, ConfigDir: opts.configDir, LockFile: lockPath})
+		if opts.lazyDir == "" {
+			opts.lazyDir = gitlocal.ProfileRoot(appName)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+		return exitFatal
+	}
+	if opts.headless {
+		// The TUI lists them instead, since it takes over the terminal.
+		for _, d := range diags {
+			fmt.Fprintln(os.Stderr, "nvimgotrack: warning:", d)
+		}
+	}
+
+	var rules *detector.Rules
+	if opts.rulesFile != "" {
+		if rules, err = detector.LoadRules(opts.rulesFile); err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitUsage
+		}
+	}
+
+	token, err := resolveToken(opts.tokenFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+		return exitFatal
+	}
+
+	creds, err := newCredentialSource(token, opts.credsFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+		return exitFatal
+	}
+
+	// Rate limit waits pause the run; report them so it does not look hung.
+	pauses := make(chan github.Pause, 1)
+	onPause := func(p github.Pause) {
+		if opts.headless {
+			fmt.Fprintf(os.Stderr, "nvimgotrack: %s hit, pausing until %s\n", p.Reason, p.Until.Local().Format(time.TimeOnly))
+			return
+		}
+		select {
+		case pauses <- p:
+		default:
+		}
+	}
+
+	forges, err := buildForges(opts, creds, plugins, onPause)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+		return exitUsage
+	}
+
+	// Local clones keep locked commits a force-push removed upstream.
+	local := gitlocal.New(opts.lazyDir, plugins, false)
+
+	// An interrupt cancels in-flight requests instead of leaving them to
+	// their HTTP timeout.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if opts.headless {
+		if opts.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+			defer cancel()
+		}
+		cfg := headlessConfig{out: os.Stdout, format: opts.format, lockfile: lockPath, profiles: profiles, diagnostics: diags, failOn: failOn, workers: opts.workers, pluginTimeout: opts.pluginTimeout, rules: rules, local: local}
+		if opts.output != "" {
+			f, err := os.Create(opts.output)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+				return exitFatal
+			}
+			defer f.Close()
+			cfg.out = f
+		}
+		return runHeadless(ctx, cfg, forges, plugins)
+	}
+
+	model := tui.NewModel(ctx, plugins, forges, tui.Options{
+		Workers:       opts.workers,
+		RunTimeout:    opts.timeout,
+		PluginTimeout: opts.pluginTimeout,
+		Rules:         rules,
+		Local:         local,
+		Pauses:        pauses,
+		Warnings:      diags,
+	})
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+		return exitFatal
+	}
+	return exitOK
+}
+
+// resolveToken returns the github.com token from tokenFile if set, otherwise
+// from GITHUB_TOKEN or GH_TOKEN. An empty token is not an error; the
+// credential source then tries gh's hosts.yml, and the client falls back to
+// unauthenticated requests.
+func resolveToken(tokenFile string) (string, error) {
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("reading token file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		github   string
+		gh       string
+		file     *string // token file contents; nil means no -token-file
+		want     string
+		wantsErr bool
+	}{
+		{name: "none"},
+		{name: "both vars", github: "gh-token-1", gh: "gh-token-2", want: "gh-token-1"},
+		{name: "GH_TOKEN only", gh: "gh-token-2", want: "gh-token-2"},
+		{name: "file only", file: ptr("file-token"), want: "file-token"},
+		{name: "file over vars", github: "gh-token-1", gh: "gh-token-2", file: ptr("file-token"), want: "file-token"},
+		{name: "trailing newline", file: ptr("file-token\n"), want: "file-token"},
+		{name: "empty file", github: "gh-token-1", file: ptr(""), want: ""},
+		{name: "missing file", file: nil, want: "", wantsErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", tt.github)
+			t.Setenv("GH_TOKEN", tt.gh)
+			tokenFile := ""
+			switch {
+			case tt.file != nil:
+				tokenFile = filepath.Join(t.TempDir(), "token")
+				if err := os.WriteFile(tokenFile, []byte(*tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			case tt.wantsErr:
+				tokenFile = filepath.Join(t.TempDir(), "missing")
+			}
+
+			got, err := resolveToken(tokenFile)
+			if (err != nil) != tt.wantsErr {
+				t.Fatalf("err = %v, wantsErr %v", err, tt.wantsErr)
+			}
+			if got != tt.want {
+				t.Errorf("token = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
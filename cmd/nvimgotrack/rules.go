@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Giankrp/nvimgotrack/internal/detector"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+const rulesUsage = "usage: nvimgotrack rules test [-rules file] [-plugin name] [messages-file ...]"
+
+// runRules implements "nvimgotrack rules test", which rates saved commit
+// messages with a rule set and prints what each one matched.
+func runRules(args []string, stdin io.Reader, stdout io.Writer) int {
+	if len(args) == 0 || args[0] != "test" {
+		fmt.Fprintln(os.Stderr, rulesUsage)
+		return exitUsage
+	}
+	fs := flag.NewFlagSet("nvimgotrack rules test", flag.ContinueOnError)
+	rulesFile := fs.String("rules", "", "rules file to test (default: built-in rules only)")
+	plugin := fs.String("plugin", "", "also apply this plugin's rules, by name or owner/repo")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), rulesUsage)
+		fmt.Fprintf(fs.Output(), "Messages are read from the files, or stdin, separated by NUL bytes (%s) or else one per line.\n", "git log --format=%B%x00")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	var rules *detector.Rules
+	if *rulesFile != "" {
+		var err error
+		if rules, err = detector.LoadRules(*rulesFile); err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitUsage
+		}
+	}
+
+	var msgs []string
+	if fs.NArg() == 0 {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitFatal
+		}
+		msgs = splitMessages(string(data))
+	}
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nvimgotrack:", err)
+			return exitFatal
+		}
+		msgs = append(msgs, splitMessages(string(data))...)
+	}
+
+	p := parser.Plugin{Name: *plugin}
+	if owner, repo, ok := strings.Cut(*plugin, "/"); ok {
+		p.Owner, p.Repo = owner, repo
+	}
+	printClassifications(stdout, rules.For(p).ClassifyMessages(msgs))
+	return exitOK
+}
+
+// splitMessages splits NUL-separated commit messages, or lines when there
+// is no NUL byte, dropping empty ones.
+func splitMessages(data string) []string {
+	sep := "\n"
+	if strings.Contains(data, "\x00") {
+		sep = "\x00"
+	}
+	var msgs []string
+	for _, m := range strings.Split(data, sep) {
+		if m = strings.TrimSpace(m); m != "" {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// printClassifications writes each message's severity and the rules that
+// matched it, followed by the totals.
+func printClassifications(w io.Writer, results []detector.Classification) {
+	var counts [detector.SeverityBreaking + 1]int
+	for _, c := range results {
+		counts[c.Severity]++
+		fmt.Fprintf(w, "%s %-12s %s\n", c.Severity.Icon(), c.Severity.Name(), truncate(strings.SplitN(c.Message, "\n", 2)[0], 80))
+		for _, f := range c.Findings {
+			fmt.Fprintf(w, "   %s: %q\n", f.Rule, f.Match())
+		}
+	}
+	fmt.Fprintf(w, "\n%d breaking, %d deprecated, %d not flagged (%d messages)\n",
+		counts[detector.SeverityBreaking], counts[detector.SeverityDeprecation],
+		counts[detector.SeverityOK]+counts[detector.SeverityFeature], len(results))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitMessages(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"feat: a\nfix: b\n\n", []string{"feat: a", "fix: b"}},
+		{"feat: a\n\nbody\n\x00fix: b\n\x00", []string{"feat: a\n\nbody", "fix: b"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitMessages(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("splitMessages(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRunRulesTest(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.json")
+	rules := `{"plugins": {"demo.nvim": {"rules": [{"name": "bracket", "pattern": "\\[BREAKING\\]", "severity": "breaking"}]}}}`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	msgs := "[BREAKING] new layout\nchore: deprecated the old module\nadd picker\n"
+
+	var out strings.Builder
+	if code := runRules([]string{"test", "-rules", rulesFile, "-plugin", "demo.nvim"}, strings.NewReader(msgs), &out); code != exitOK {
+		t.Fatalf("exit code = %d, output:\n%s", code, out.String())
+	}
+	for _, want := range []string{`bracket: "[BREAKING]"`, `deprecation keyword: "deprecated"`, "1 breaking, 1 deprecated, 1 not flagged (3 messages)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if code := runRules([]string{"check"}, strings.NewReader(""), &out); code != exitUsage {
+		t.Errorf("unknown subcommand: exit code = %d, want %d", code, exitUsage)
+	}
+}
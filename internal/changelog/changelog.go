@@ -0,0 +1,159 @@
+// Package changelog splits a Markdown CHANGELOG or NEWS file into one
+// section per version and picks out the breaking changes and deprecations
+// each one lists, as release-please's "⚠ BREAKING CHANGES" subsections do.
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/semver"
+)
+
+// Files are the names looked up in a repository's root, in order.
+var Files = []string{"CHANGELOG.md", "NEWS.md"}
+
+// Section is the part of a changelog under one version heading.
+type Section struct {
+	Heading string    // heading text without the leading #s
+	Version string    // e.g. "11.0.0"; empty for an "Unreleased" section
+	Date    time.Time // zero when the heading has no date
+	Body    string
+
+	// Breaking and Deprecations are the list items under subsections
+	// whose heading mentions breaking changes or deprecations.
+	Breaking     []string
+	Deprecations []string
+	// BreakingHeading and DeprecationHeading are those subsections'
+	// headings, e.g. "⚠ BREAKING CHANGES".
+	BreakingHeading    string
+	DeprecationHeading string
+}
+
+var (
+	headingRe    = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	versionRe    = regexp.MustCompile(`\bv?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.]+)?)\b`)
+	dateRe       = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`)
+	unreleasedRe = regexp.MustCompile(`(?i)\bunreleased\b`)
+	breakingRe   = regexp.MustCompile(`(?i)breaking`)
+	deprecRe     = regexp.MustCompile(`(?i)deprecat`)
+	itemRe       = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	linkRe       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	refsRe       = regexp.MustCompile(`(\s*\((?:#\d+|[0-9a-f]{7,40})\))+$`)
+)
+
+// Parse splits text at its version headings, newest first as written.
+// The level of the first version heading is the section level; deeper
+// headings stay in the section body. Text before it is dropped.
+func Parse(text string) []Section {
+	var (
+		sections []Section
+		level    int
+		body     []string
+	)
+	flush := func() {
+		if len(sections) == 0 {
+			return
+		}
+		s := &sections[len(sections)-1]
+		s.Body = strings.TrimSpace(strings.Join(body, "\n"))
+		s.parseBody()
+		body = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		m := headingRe.FindStringSubmatch(line)
+		if m != nil && (level == 0 || len(m[1]) <= level) {
+			if s, ok := parseHeading(m[2]); ok {
+				flush()
+				level = len(m[1])
+				sections = append(sections, s)
+				continue
+			}
+		}
+		if len(sections) > 0 {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return sections
+}
+
+// Since returns the sections newer than the locked version, along with
+// any Unreleased one. Sections whose version does not parse are left out.
+func Since(sections []Section, locked semver.Version) []Section {
+	var out []Section
+	for _, s := range sections {
+		if s.Version == "" {
+			out = append(out, s)
+			continue
+		}
+		if v, ok := semver.Parse(s.Version); ok && v.Compare(locked) > 0 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// After returns the sections dated after t, along with any Unreleased
+// one. It serves changelogs of repositories without version tags.
+func After(sections []Section, t time.Time) []Section {
+	var out []Section
+	for _, s := range sections {
+		if s.Version == "" || s.Date.After(t) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseHeading reads the version and date of a section heading such as
+// "[11.0.0](https://…) (2024-01-15)" or "v0.3 - 2023-06-01".
+func parseHeading(heading string) (Section, bool) {
+	text := linkRe.ReplaceAllString(heading, "$1")
+	s := Section{Heading: strings.TrimSpace(text)}
+	// Skip dates, which the version pattern would also match in part.
+	if m := versionRe.FindStringSubmatch(dateRe.ReplaceAllString(text, "")); m != nil {
+		s.Version = m[1]
+	} else if !unreleasedRe.MatchString(text) {
+		return Section{}, false
+	}
+	if m := dateRe.FindStringSubmatch(text); m != nil {
+		s.Date, _ = time.Parse(time.DateOnly, m[1])
+	}
+	return s, true
+}
+
+// parseBody collects the items of the breaking and deprecation
+// subsections.
+func (s *Section) parseBody() {
+	var current *[]string
+	for _, line := range strings.Split(s.Body, "\n") {
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			current = nil
+			switch heading := strings.TrimSpace(m[2]); {
+			case breakingRe.MatchString(heading):
+				current, s.BreakingHeading = &s.Breaking, heading
+			case deprecRe.MatchString(heading):
+				current, s.DeprecationHeading = &s.Deprecations, heading
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if m := itemRe.FindStringSubmatch(line); m != nil {
+			*current = append(*current, cleanItem(m[1]))
+		}
+	}
+}
+
+// cleanItem strips the Markdown emphasis and links from a list item.
+func cleanItem(item string) string {
+	item = linkRe.ReplaceAllString(item, "$1")
+	item = strings.NewReplacer("**", "", "__", "", "`", "").Replace(item)
+	// release-please appends "(abc1234)" or "(#123)" references.
+	item = refsRe.ReplaceAllString(item, "")
+	return strings.TrimSpace(item)
+}
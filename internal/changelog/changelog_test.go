@@ -0,0 +1,89 @@
+package changelog
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/semver"
+)
+
+const releasePlease = `# Changelog
+
+## [Unreleased]
+
+* tweak defaults
+
+## [11.0.0](https://github.com/folke/lazy.nvim/compare/v10.24.3...v11.0.0) (2024-06-25)
+
+
+### ⚠ BREAKING CHANGES
+
+* **spec:** removed the ` + "`config`" + ` alias ([abc1234](https://github.com/folke/lazy.nvim/commit/abc1234))
+* new install layout ([#1500](https://github.com/folke/lazy.nvim/issues/1500))
+
+### Features
+
+* **ui:** show build output ([def5678](https://github.com/folke/lazy.nvim/commit/def5678))
+
+## [10.24.3](https://github.com/folke/lazy.nvim/compare/v10.24.2...v10.24.3) (2024-06-01)
+
+### Deprecations
+
+- ` + "`opts.dev.path`" + ` in favor of ` + "`dev.dir`" + `
+
+## 10.24.2 (2024-05-20)
+
+### Bug Fixes
+
+* 1.2 is not a heading
+`
+
+func TestParse(t *testing.T) {
+	sections := Parse(releasePlease)
+	var versions []string
+	for _, s := range sections {
+		versions = append(versions, s.Version)
+	}
+	if want := []string{"", "11.0.0", "10.24.3", "10.24.2"}; !slices.Equal(versions, want) {
+		t.Fatalf("versions = %q, want %q", versions, want)
+	}
+
+	major := sections[1]
+	if want := time.Date(2024, 6, 25, 0, 0, 0, 0, time.UTC); !major.Date.Equal(want) {
+		t.Errorf("date = %v, want %v", major.Date, want)
+	}
+	if want := []string{"spec: removed the config alias", "new install layout"}; !slices.Equal(major.Breaking, want) {
+		t.Errorf("breaking = %q, want %q", major.Breaking, want)
+	}
+	if major.BreakingHeading != "⚠ BREAKING CHANGES" {
+		t.Errorf("breaking heading = %q", major.BreakingHeading)
+	}
+	if want := []string{"opts.dev.path in favor of dev.dir"}; !slices.Equal(sections[2].Deprecations, want) {
+		t.Errorf("deprecations = %q, want %q", sections[2].Deprecations, want)
+	}
+	if len(sections[3].Breaking) != 0 || sections[3].Heading != "10.24.2 (2024-05-20)" {
+		t.Errorf("last section = %+v", sections[3])
+	}
+}
+
+func TestSince(t *testing.T) {
+	sections := Parse(releasePlease)
+	locked, _ := semver.Parse("v10.24.2")
+
+	var got []string
+	for _, s := range Since(sections, locked) {
+		got = append(got, s.Version)
+	}
+	if want := []string{"", "11.0.0", "10.24.3"}; !slices.Equal(got, want) {
+		t.Errorf("Since(10.24.2) = %q, want %q", got, want)
+	}
+
+	got = nil
+	for _, s := range After(sections, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)) {
+		got = append(got, s.Version)
+	}
+	if want := []string{"", "11.0.0"}; !slices.Equal(got, want) {
+		t.Errorf("After(2024-06-10) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Package conventional parses commit messages written to the Conventional
+// Commits 1.0 specification, such as "feat(api)!: drop setup()" followed
+// by an optional body and "BREAKING CHANGE: ..." footers.
+package conventional
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Footer is a "Token: value" or "Token #value" trailer. Values may span
+// several lines.
+type Footer struct {
+	Token string
+	Value string
+}
+
+// Commit is a parsed commit message.
+type Commit struct {
+	Type        string // lowercased, e.g. "feat"
+	Scope       string
+	Bang        bool // "!" before the colon
+	Description string
+	Body        string
+	Footers     []Footer
+}
+
+var (
+	headerRe = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()\r\n]*)\))?(!)?: +(\S.*)$`)
+	footerRe = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[A-Za-z][\w-]*)(:(?: |$)| #)(.*)$`)
+)
+
+// Parse parses msg. Messages whose first line is not a Conventional
+// Commits header return false.
+func Parse(msg string) (Commit, bool) {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(msg), "\r\n", "\n"), "\n")
+	m := headerRe.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if m == nil {
+		return Commit{}, false
+	}
+	c := Commit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       strings.TrimSpace(m[2]),
+		Bang:        m[3] == "!",
+		Description: strings.TrimSpace(m[4]),
+	}
+
+	// Footers are the last paragraph, when it starts with a trailer;
+	// everything before them is the body.
+	rest := lines[1:]
+	start := len(rest)
+	for start > 0 && strings.TrimSpace(rest[start-1]) != "" {
+		start--
+	}
+	if start < len(rest) {
+		if _, ok := parseFooter(rest[start]); !ok {
+			start = len(rest)
+		}
+	}
+	c.Body = strings.TrimSpace(strings.Join(rest[:start], "\n"))
+	for _, line := range rest[start:] {
+		if f, ok := parseFooter(line); ok {
+			c.Footers = append(c.Footers, f)
+			continue
+		}
+		last := &c.Footers[len(c.Footers)-1]
+		last.Value = strings.TrimSpace(last.Value + "\n" + line)
+	}
+	return c, true
+}
+
+// parseFooter parses a trailer line. Only BREAKING CHANGE may leave its
+// value to the lines after it.
+func parseFooter(line string) (Footer, bool) {
+	m := footerRe.FindStringSubmatch(line)
+	if m == nil || (m[2] == ":" && !isBreaking(m[1])) {
+		return Footer{}, false
+	}
+	return Footer{Token: m[1], Value: strings.TrimSpace(m[3])}, true
+}
+
+func isBreaking(token string) bool {
+	return token == "BREAKING CHANGE" || token == "BREAKING-CHANGE"
+}
+
+// Breaking reports whether the commit marks a breaking change, with "!"
+// in the header or a BREAKING CHANGE footer.
+func (c Commit) Breaking() bool {
+	_, ok := c.BreakingFooter()
+	return c.Bang || ok
+}
+
+// BreakingNote returns the value of the first BREAKING CHANGE (or
+// BREAKING-CHANGE) footer.
+func (c Commit) BreakingNote() string {
+	f, _ := c.BreakingFooter()
+	return f.Value
+}
+
+// BreakingFooter returns the first BREAKING CHANGE or BREAKING-CHANGE
+// footer.
+func (c Commit) BreakingFooter() (Footer, bool) {
+	for _, f := range c.Footers {
+		if isBreaking(f.Token) {
+			return f, true
+		}
+	}
+	return Footer{}, false
+}
+
+// Header returns the type, scope and "!" as written before the colon,
+// e.g. "feat(api)!".
+func (c Commit) Header() string {
+	h := c.Type
+	if c.Scope != "" {
+		h += "(" + c.Scope + ")"
+	}
+	if c.Bang {
+		h += "!"
+	}
+	return h
+}
@@ -0,0 +1,88 @@
+package conventional
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want Commit
+		ok   bool
+	}{
+		{
+			msg:  "feat: add picker",
+			want: Commit{Type: "feat", Description: "add picker"},
+			ok:   true,
+		},
+		{
+			msg:  "Perf(lsp)!: drop nvim 0.9",
+			want: Commit{Type: "perf", Scope: "lsp", Bang: true, Description: "drop nvim 0.9"},
+			ok:   true,
+		},
+		{
+			msg: "fix(ui): keep cursor\n\nThe cursor jumped on redraw.\nNote this line.\n\nRefs #42\nBREAKING CHANGE: `opts.keep` is now\n  required\nReviewed-by: A",
+			want: Commit{
+				Type:        "fix",
+				Scope:       "ui",
+				Description: "keep cursor",
+				Body:        "The cursor jumped on redraw.\nNote this line.",
+				Footers: []Footer{
+					{Token: "Refs", Value: "42"},
+					{Token: "BREAKING CHANGE", Value: "`opts.keep` is now\n  required"},
+					{Token: "Reviewed-by", Value: "A"},
+				},
+			},
+			ok: true,
+		},
+		{
+			// A trailer-like line outside the last paragraph is body text.
+			msg: "docs: explain setup\n\nNote: call setup() once.\n\nSee the wiki.",
+			want: Commit{
+				Type:        "docs",
+				Description: "explain setup",
+				Body:        "Note: call setup() once.\n\nSee the wiki.",
+			},
+			ok: true,
+		},
+		{
+			msg: "feat: x\n\nBREAKING CHANGE:\n`setup()` is required.",
+			want: Commit{
+				Type:        "feat",
+				Description: "x",
+				Footers:     []Footer{{Token: "BREAKING CHANGE", Value: "`setup()` is required."}},
+			},
+			ok: true,
+		},
+		{msg: "Merge pull request #3 from a/b", ok: false},
+		{msg: "feat:missing space", ok: false},
+		{msg: "Update README.md", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.msg)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.msg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBreaking(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"feat!: x", true},
+		{"feat: x\n\nBREAKING-CHANGE: y", true},
+		{"feat: x\n\nBREAKING CHANGE: y", true},
+		{"feat: x\n\nbreaking change: y", false},
+		{"feat: x\n\nBREAKING CHANGE:\nsetup() is required", true},
+		{"fix: removed a typo", false},
+	}
+	for _, tt := range tests {
+		c, _ := Parse(tt.msg)
+		if got := c.Breaking(); got != tt.want {
+			t.Errorf("Parse(%q).Breaking() = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+)
+
+// branchSource is implemented by sources that can tell whether a branch
+// still exists, so a lockfile pinned to a deleted branch is told apart
+// from other compare failures.
+type branchSource interface {
+	HasBranch(ctx context.Context, owner, repo, branch string) (bool, error)
+}
+
+// compareHead returns the branch to compare the locked commit against:
+// the locked branch, or the default branch when the lockfile names none or
+// the locked one no longer exists upstream (e.g. after a master → main
+// rename).
+func (r *PluginReport) compareHead(ctx context.Context, client Source, owner, repo string) string {
+	branch := r.Plugin.Branch
+	if branch == "" {
+		return r.Health.DefaultBranch
+	}
+	if !r.Health.BranchMismatch {
+		return branch
+	}
+	src, ok := client.(branchSource)
+	if !ok {
+		return branch
+	}
+	if exists, err := src.HasBranch(ctx, owner, repo, branch); err != nil || exists {
+		return branch
+	}
+	r.Health.BranchMissing = true
+	return r.Health.DefaultBranch
+}
+
+// checkDivergence records how the compared branch relates to the locked
+// commit. BehindBy in a compare result counts commits only the base has,
+// i.e. locked commits the branch no longer contains.
+func (r *PluginReport) checkDivergence(c *github.CompareResult) {
+	r.CompareStatus = c.Status
+	r.LockedOnly = c.BehindBy
+}
+
+// Diverged reports whether the locked commit is not on the compared
+// branch, because its history was rewritten or the commit came from
+// another branch.
+func (r PluginReport) Diverged() bool {
+	return r.CompareStatus == "diverged" || r.LockedOnly > 0
+}
+
+// BranchNote explains a branch problem in one sentence, or returns "".
+func (r PluginReport) BranchNote() string {
+	h := r.Health
+	switch {
+	case h.BranchMissing:
+		return fmt.Sprintf("locked branch %q no longer exists; compared against the default branch %q",
+			r.Plugin.Branch, h.DefaultBranch)
+	case r.Diverged():
+		branch := r.Plugin.Branch
+		if branch == "" {
+			branch = h.DefaultBranch
+		}
+		return fmt.Sprintf("diverged: %d locked commit(s) are not on %q; its history was rewritten or the commit came from another branch",
+			r.LockedOnly, branch)
+	case h.BranchMismatch:
+		return fmt.Sprintf("locked branch %q is not the default branch %q", r.Plugin.Branch, h.DefaultBranch)
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/changelog"
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/semver"
+)
+
+// Rules recorded for changelog findings.
+const (
+	ruleChangelogBreaking    = "changelog breaking changes"
+	ruleChangelogDeprecation = "changelog deprecations"
+)
+
+// changelogSource is implemented by sources that can read a file from the
+// repository, which lets Analyze read its changelog.
+type changelogSource interface {
+	GetFile(ctx context.Context, owner, repo, path, ref string) (*github.FileContent, error)
+}
+
+// ChangelogSection is a changelog entry between the locked version and
+// the analyzed head.
+type ChangelogSection struct {
+	Version      string   `json:"version,omitempty"` // empty for "Unreleased"
+	Heading      string   `json:"heading"`
+	Breaking     []string `json:"breaking,omitempty"`
+	Deprecations []string `json:"deprecations,omitempty"`
+	URL          string   `json:"url,omitempty"`
+}
+
+// checkChangelog reads CHANGELOG.md or NEWS.md at head and turns the
+// sections added since the locked version into findings. The Unreleased
+// section is kept apart without findings. Sections past a constrained
+// plugin's Target are left out, as are all of them, with a note, when the
+// locked version cannot be placed.
+func (r *PluginReport) checkChangelog(ctx context.Context, client Source, owner, repo, base, head string, compare *github.CompareResult, releases []github.Release, tgt target, rs *RuleSet) {
+	src, ok := client.(changelogSource)
+	if !ok {
+		return
+	}
+	var file *github.FileContent
+	for _, name := range changelog.Files {
+		if f, err := src.GetFile(ctx, owner, repo, name, head); err == nil {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return
+	}
+
+	sections := changelog.Parse(file.Content)
+	lockedAt := compare.BaseCommit.Commit.Author.Date
+	if _, tagged := semver.Parse(r.Plugin.Locked()); lockedAt.IsZero() && !tagged {
+		// Only some sources fill in the compare's base commit.
+		if src, ok := client.(CommitSource); ok {
+			if c, err := src.GetCommit(ctx, owner, repo, base); err == nil {
+				lockedAt = c.Commit.Author.Date
+			}
+		}
+	}
+	if locked, ok := lockedVersion(r.Plugin.Locked(), releases, lockedAt); ok {
+		sections = changelog.Since(sections, locked)
+	} else if !lockedAt.IsZero() {
+		sections = changelog.After(sections, lockedAt)
+	} else {
+		r.ChangelogNote = fmt.Sprintf("%s not read: the locked commit could not be dated", file.Path)
+		return
+	}
+
+	for _, s := range sections {
+		if constrained(r.Plugin) && (s.Version == "" || tgt.beyondTarget(s.Version)) {
+			continue
+		}
+		if s.Version == "" {
+			r.Unreleased = &ChangelogSection{
+				Heading:      s.Heading,
+				Breaking:     s.Breaking,
+				Deprecations: s.Deprecations,
+				URL:          file.HTMLURL,
+			}
+			continue
+		}
+		var candidates []Finding
+		for _, item := range s.Breaking {
+			candidates = append(candidates, sectionFinding(s.BreakingHeading, item, SeverityBreaking, ruleChangelogBreaking))
+		}
+		for _, item := range s.Deprecations {
+			candidates = append(candidates, sectionFinding(s.DeprecationHeading, item, SeverityDeprecation, ruleChangelogDeprecation))
+		}
+		candidates = append(candidates, rs.match(SourceChangelog, s.Body)...)
+
+		_, kept := rate(candidates)
+		for _, f := range kept {
+			f.Ref, f.URL = s.Version, file.HTMLURL
+			r.Findings = append(r.Findings, f)
+		}
+		r.Changelog = append(r.Changelog, ChangelogSection{
+			Version:      s.Version,
+			Heading:      s.Heading,
+			Breaking:     s.Breaking,
+			Deprecations: s.Deprecations,
+			URL:          file.HTMLURL,
+		})
+	}
+}
+
+// sectionFinding records a changelog item as "heading: item", matching the
+// heading it was listed under.
+func sectionFinding(heading, item string, sev Severity, rule string) Finding {
+	return Finding{
+		Source:   SourceChangelog,
+		Severity: sev,
+		Rule:     rule,
+		Text:     heading + ": " + item,
+		End:      len(heading),
+		Weight:   1,
+	}
+}
+
+// lockedVersion places the locked commit among the versions: the commit
+// itself when the lockfile records a version tag, else the newest release
+// published no later than the commit was made.
+func lockedVersion(commit string, releases []github.Release, lockedAt time.Time) (semver.Version, bool) {
+	if v, ok := semver.Parse(commit); ok {
+		return v, true
+	}
+	var (
+		best  semver.Version
+		found bool
+	)
+	if lockedAt.IsZero() {
+		return best, false
+	}
+	for _, rel := range releases {
+		if rel.Draft || rel.PublishedAt.After(lockedAt) {
+			continue
+		}
+		if v, ok := semver.Parse(rel.TagName); ok && (!found || v.Compare(best) > 0) {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
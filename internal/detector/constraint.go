@@ -0,0 +1,95 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+	"github.com/Giankrp/nvimgotrack/internal/semver"
+)
+
+// target is the ref lazy.nvim would update a constrained plugin to.
+type target struct {
+	ref     string          // tag name, or the locked commit when nothing is reachable
+	version *semver.Version // nil unless ref is a version tag
+	none    bool            // no update is reachable
+}
+
+// tagSource is implemented by sources that list every tag of a
+// repository, including those never published as a release.
+type tagSource interface {
+	ListTags(ctx context.Context, owner, repo string) ([]string, error)
+}
+
+// versionTags returns the tags a version range is resolved against: every
+// tag when the source can list them, otherwise the non-draft release tags.
+func versionTags(ctx context.Context, client Source, owner, repo string, releases []github.Release, releasesErr error) ([]string, error) {
+	if src, ok := client.(tagSource); ok {
+		return src.ListTags(ctx, owner, repo)
+	}
+	if releasesErr != nil {
+		return nil, releasesErr
+	}
+	tags := make([]string, 0, len(releases))
+	for _, rel := range releases {
+		if !rel.Draft {
+			tags = append(tags, rel.TagName)
+		}
+	}
+	return tags, nil
+}
+
+// constrained reports whether the spec limits which commits lazy.nvim will
+// pull in, via `pin`, `commit`, `tag` or `version`.
+func constrained(plugin parser.Plugin) bool {
+	return plugin.Pin || plugin.SpecCommit != "" || plugin.Tag != "" || plugin.Version != ""
+}
+
+// versionRanged reports whether updateTarget resolves the spec's version
+// range, that is when no pin, commit or tag takes precedence over it.
+func versionRanged(plugin parser.Plugin) bool {
+	return plugin.Version != "" && !plugin.Pin && plugin.SpecCommit == "" && plugin.Tag == ""
+}
+
+// updateTarget picks the reachable update for a constrained plugin: nothing
+// when pinned, the spec's commit or tag when one is given, otherwise the
+// newest of tags inside the version range.
+func updateTarget(plugin parser.Plugin, tags []string) (target, error) {
+	switch {
+	case plugin.Pin:
+		return target{ref: plugin.Locked(), none: true}, nil
+	case plugin.SpecCommit != "":
+		return target{ref: plugin.SpecCommit, none: plugin.SpecCommit == plugin.Commit}, nil
+	case plugin.Tag != "":
+		t := target{ref: plugin.Tag}
+		if v, ok := semver.Parse(plugin.Tag); ok {
+			t.version = &v
+		}
+		return t, nil
+	}
+
+	r, err := semver.ParseRange(plugin.Version)
+	if err != nil {
+		return target{}, fmt.Errorf("version constraint: %w", err)
+	}
+	tag, v, ok := r.Latest(tags)
+	if !ok {
+		return target{ref: plugin.Locked(), none: true}, nil
+	}
+	return target{ref: tag, version: &v}, nil
+}
+
+// beyondTarget reports whether a release is newer than the reachable
+// target. Releases cannot be ordered against a non-version tag, so none of
+// them count as beyond it.
+func (t target) beyondTarget(tag string) bool {
+	if t.none {
+		return true
+	}
+	if t.version == nil {
+		return false
+	}
+	v, ok := semver.Parse(tag)
+	return ok && v.Compare(*t.version) > 0
+}
@@ -1,12 +1,15 @@
 package detector
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Giankrp/nvimgotrack/internal/conventional"
 	"github.com/Giankrp/nvimgotrack/internal/github"
 	"github.com/Giankrp/nvimgotrack/internal/parser"
 )
@@ -14,10 +17,10 @@ import (
 type Severity int
 
 const (
-	SeverityOK          Severity = iota 
-	SeverityFeature                     
-	SeverityDeprecation                
-	SeverityBreaking                    
+	SeverityOK Severity = iota
+	SeverityFeature
+	SeverityDeprecation
+	SeverityBreaking
 )
 
 func (s Severity) String() string {
@@ -33,6 +36,43 @@ func (s Severity) String() string {
 	}
 }
 
+// Name returns the stable machine-readable name used in JSON reports.
+func (s Severity) Name() string {
+	switch s {
+	case SeverityBreaking:
+		return "breaking"
+	case SeverityDeprecation:
+		return "deprecation"
+	case SeverityFeature:
+		return "feature"
+	default:
+		return "ok"
+	}
+}
+
+// ParseSeverity is the inverse of Severity.Name.
+func ParseSeverity(name string) (Severity, error) {
+	for s := SeverityOK; s <= SeverityBreaking; s++ {
+		if s.Name() == name {
+			return s, nil
+		}
+	}
+	return SeverityOK, fmt.Errorf("unknown severity %q", name)
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.Name()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
 func (s Severity) Icon() string {
 	switch s {
 	case SeverityBreaking:
@@ -47,36 +87,148 @@ func (s Severity) Icon() string {
 }
 
 type PluginReport struct {
-	Plugin       parser.Plugin
-	Severity     Severity
-	BehindBy     int
-	Releases     []ReleaseInfo
-	BreakingMsgs []string
-	DeprecMsgs   []string
-	Error        string
-	CompareURL   string
+	Plugin       parser.Plugin   `json:"plugin"`
+	Severity     Severity        `json:"severity"`
+	BehindBy     int             `json:"behind_by"`
+	Releases     []ReleaseInfo   `json:"releases,omitempty"`
+	BreakingMsgs []CommitMessage `json:"breaking,omitempty"`
+	DeprecMsgs   []CommitMessage `json:"deprecations,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	CompareURL   string          `json:"compare_url,omitempty"`
+	// Partial is set when the forge listed fewer commits than the plugin
+	// is behind by, so breaking changes in the rest may have been missed.
+	// BehindBy is then a lower bound if the forge could not count them.
+	Partial bool `json:"partial,omitempty"`
+
+	// Target is the tag (or, when nothing is reachable, the locked commit)
+	// that the spec's pin, tag or version constraint allows updating to.
+	// It is empty for plugins that follow their branch.
+	Target string `json:"target,omitempty"`
+	// BeyondBy and BeyondBreaking cover commits on the branch past Target,
+	// which the constraint keeps out and which do not affect Severity.
+	BeyondBy       int             `json:"beyond_by,omitempty"`
+	BeyondBreaking []CommitMessage `json:"beyond_breaking,omitempty"`
+
+	// MovedTo is the canonical "owner/repo" of a renamed or transferred
+	// repository, and SpecFix the suggested change to the plugin spec.
+	MovedTo string `json:"moved_to,omitempty"`
+	SpecFix string `json:"spec_fix,omitempty"`
+
+	// CompareStatus is the forge's relation of the compared branch to the
+	// locked commit: "ahead", "behind", "diverged" or "identical".
+	// LockedOnly counts locked commits the branch does not contain.
+	CompareStatus string `json:"compare_status,omitempty"`
+	LockedOnly    int    `json:"locked_only,omitempty"`
+
+	// Rewritten is set when the locked commit was removed from upstream's
+	// history, e.g. by a force-push. NearestCommit and NearestTag are the
+	// newest commit on the branch and release made no later than it; the
+	// analysis then runs from NearestCommit.
+	Rewritten     bool   `json:"history_rewritten,omitempty"`
+	NearestCommit string `json:"nearest_commit,omitempty"`
+	NearestTag    string `json:"nearest_tag,omitempty"`
+
+	Health Health `json:"health"`
+
+	// Findings are the signals Severity was derived from.
+	Findings []Finding `json:"findings,omitempty"`
+	// Changelog holds the changelog sections newer than the locked
+	// version, when the repository keeps one. Unreleased is its section of
+	// unreleased changes, listed apart since it does not count toward
+	// Severity. ChangelogNote says why a changelog was found but not read.
+	Changelog     []ChangelogSection `json:"changelog,omitempty"`
+	Unreleased    *ChangelogSection  `json:"unreleased,omitempty"`
+	ChangelogNote string             `json:"changelog_note,omitempty"`
+}
+
+// CommitMessage is the first line of a flagged commit message.
+type CommitMessage struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+
+	// Type and Scope come from a Conventional Commits header, and Note
+	// from its BREAKING CHANGE footer. Signal says what flagged the commit.
+	Type   string `json:"type,omitempty"`
+	Scope  string `json:"scope,omitempty"`
+	Note   string `json:"note,omitempty"`
+	Signal string `json:"signal,omitempty"`
 }
 
 type ReleaseInfo struct {
-	Tag      string
-	Name     string
-	Body     string
-	URL      string
-	Severity Severity
+	Tag      string   `json:"tag"`
+	Name     string   `json:"name,omitempty"`
+	Body     string   `json:"body,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Severity Severity `json:"severity"`
+
+	// BeyondRange marks releases newer than the report's Target.
+	BeyondRange bool `json:"beyond_range,omitempty"`
 }
 
 var (
 	breakingRe = regexp.MustCompile(`(?i)\b(breaking|BREAKING CHANGE|incompatible|removed|migration required)\b`)
 	deprecRe   = regexp.MustCompile(`(?i)\b(deprecated|deprecation|will be removed|no longer supported)\b`)
 	semverRe   = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)`)
-	featBangRe = regexp.MustCompile(`(?m)^(feat|fix|refactor|chore)!:`)
 )
 
-func Analyze(client *github.Client, plugin parser.Plugin) PluginReport {
+// Source provides the upstream data Analyze needs. Both the REST
+// *github.Client and the batched *github.GraphQLClient implement it.
+type Source interface {
+	CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error)
+	GetReleases(ctx context.Context, owner, repo string) ([]github.Release, error)
+}
+
+// repoInfoSource is implemented by sources that can look up repository
+// metadata, which reveals maintenance health and renamed repositories.
+type repoInfoSource interface {
+	GetRepoInfo(ctx context.Context, owner, repo string) (*github.RepoInfo, error)
+}
+
+// Analyze compares plugin's locked commit with upstream and classifies
+// what changed. It stops early, reporting an error, once ctx is done.
+func Analyze(ctx context.Context, client Source, plugin parser.Plugin) PluginReport {
+	return AnalyzeWithRules(ctx, client, plugin, nil)
+}
+
+// AnalyzeWithRules is Analyze with user detection rules added to the
+// built-in ones.
+func AnalyzeWithRules(ctx context.Context, client Source, plugin parser.Plugin, rules *Rules) PluginReport {
+	return analyze(ctx, client, plugin, rules, nil)
+}
+
+// analyze is AnalyzeWithRules, reading locked commits upstream lost from
+// local when it is not nil.
+func analyze(ctx context.Context, client Source, plugin parser.Plugin, rules *Rules, local CommitSource) PluginReport {
 	report := PluginReport{Plugin: plugin}
+	rs := rules.For(plugin)
+	owner, repo := plugin.Owner, plugin.Repo
+
+	// 0. Look up the repository's health and canonical name. Forges answer
+	// requests for a moved repository with a redirect, and its metadata
+	// with the new name.
+	if src, ok := client.(repoInfoSource); ok {
+		info, err := src.GetRepoInfo(ctx, owner, repo)
+		report.checkHealth(info, err, time.Now())
+		if err == nil {
+			report.checkMoved(info)
+		}
+	}
 
 	// 1. Compare commits
-	compare, err := client.CompareCommits(plugin.Owner, plugin.Repo, plugin.Commit, plugin.Branch)
+	head := report.compareHead(ctx, client, owner, repo)
+	base := plugin.Locked()
+	compare, err := client.CompareCommits(ctx, owner, repo, base, head)
+	if err != nil && report.MovedTo != "" {
+		// Not every forge redirects API calls; retry under the new name.
+		newOwner, newRepo := splitFullName(report.MovedTo)
+		if compare, err = client.CompareCommits(ctx, newOwner, newRepo, base, head); err == nil {
+			owner, repo = newOwner, newRepo
+		}
+	}
+	if err != nil {
+		base, compare, err = report.recoverRewritten(ctx, client, local, owner, repo, head, err)
+	}
 	if err != nil {
 		report.Error = fmt.Sprintf("compare failed: %v", err)
 		return report
@@ -84,49 +236,192 @@ func Analyze(client *github.Client, plugin parser.Plugin) PluginReport {
 
 	report.BehindBy = compare.TotalCommits
 	report.CompareURL = compare.HTMLURL
+	report.Partial = compare.Truncated || len(compare.Commits) < compare.TotalCommits
+	report.checkDivergence(compare)
 
 	if compare.TotalCommits == 0 {
 		report.Severity = SeverityOK
 		return report
 	}
 
-	for _, c := range compare.Commits {
-		msg := c.Commit.Message
-		firstLine := strings.SplitN(msg, "\n", 2)[0]
+	releases, releasesErr := client.GetReleases(ctx, owner, repo)
 
-		if breakingRe.MatchString(msg) || featBangRe.MatchString(msg) {
-			report.BreakingMsgs = append(report.BreakingMsgs, firstLine)
-		} else if deprecRe.MatchString(msg) {
-			report.DeprecMsgs = append(report.DeprecMsgs, firstLine)
+	// 2. Split off commits past the reachable update when the spec is
+	// constrained by pin, tag or version.
+	conventionalRepo := usesConventionalCommits(compare.Commits)
+	reachable := compare.Commits
+	var tgt target
+	if constrained(plugin) {
+		var tags []string
+		if versionRanged(plugin) {
+			tags, err = versionTags(ctx, client, owner, repo, releases, releasesErr)
+			if err != nil {
+				report.Error = fmt.Sprintf("listing tags for version %s failed: %v", plugin.Version, err)
+				return report
+			}
+		}
+		tgt, err = updateTarget(plugin, tags)
+		if err != nil {
+			report.Error = err.Error()
+			return report
+		}
+		report.Target = tgt.ref
+
+		reachable = nil
+		report.BehindBy = 0
+		if !tgt.none {
+			reach, err := client.CompareCommits(ctx, owner, repo, base, tgt.ref)
+			if err != nil {
+				report.Error = fmt.Sprintf("compare with %s failed: %v", tgt.ref, err)
+				return report
+			}
+			reachable = reach.Commits
+			report.BehindBy = reach.TotalCommits
+			report.CompareURL = reach.HTMLURL
+			report.Partial = reach.Truncated || len(reach.Commits) < reach.TotalCommits
+		}
+
+		inRange := make(map[string]bool, len(reachable))
+		for _, c := range reachable {
+			inRange[c.SHA] = true
+		}
+		for _, c := range compare.Commits {
+			if inRange[c.SHA] {
+				continue
+			}
+			report.BeyondBy++
+			if cm, sev, _ := rs.rateCommit(ctx, client, owner, repo, c, conventionalRepo); sev == SeverityBreaking {
+				report.BeyondBreaking = append(report.BeyondBreaking, cm)
+			}
+		}
+	}
+
+	for _, c := range reachable {
+		cm, sev, findings := rs.rateCommit(ctx, client, owner, repo, c, conventionalRepo)
+		switch sev {
+		case SeverityBreaking:
+			report.BreakingMsgs = append(report.BreakingMsgs, cm)
+		case SeverityDeprecation:
+			report.DeprecMsgs = append(report.DeprecMsgs, cm)
 		}
+		report.Findings = append(report.Findings, findings...)
 	}
-	releases, err := client.GetReleases(plugin.Owner, plugin.Repo)
-	if err == nil {
-		report.Releases = analyzeReleases(releases, plugin.Commit)
+	if releasesErr == nil {
+		var findings []Finding
+		report.Releases, findings = rs.analyzeReleases(releases, base)
+		beyond := make(map[string]bool)
+		if report.Target != "" {
+			for i := range report.Releases {
+				report.Releases[i].BeyondRange = tgt.beyondTarget(report.Releases[i].Tag)
+				beyond[report.Releases[i].Tag] = report.Releases[i].BeyondRange
+			}
+		}
+		for _, f := range findings {
+			if !beyond[f.Ref] {
+				report.Findings = append(report.Findings, f)
+			}
+		}
 	}
 
+	// 3. Read what the changelog lists since the locked version.
+	report.checkChangelog(ctx, client, owner, repo, base, head, compare, releases, tgt, rs)
+
 	report.Severity = SeverityOK
 	if report.BehindBy > 0 {
 		report.Severity = SeverityFeature
 	}
+	for _, f := range report.Findings {
+		report.Severity = max(report.Severity, f.Severity)
+	}
+
+	return report
+}
 
-	for _, r := range report.Releases {
-		if r.Severity > report.Severity {
-			report.Severity = r.Severity
+// usesConventionalCommits reports whether at least half of commits follow
+// Conventional Commits, in which case unstructured ones are not searched
+// for breaking keywords.
+func usesConventionalCommits(commits []github.Commit) bool {
+	n := 0
+	for _, c := range commits {
+		if _, ok := conventional.Parse(c.Commit.Message); ok {
+			n++
 		}
 	}
+	return len(commits) > 0 && 2*n >= len(commits)
+}
 
-	if len(report.BreakingMsgs) > 0 {
-		report.Severity = SeverityBreaking
-	} else if len(report.DeprecMsgs) > 0 && report.Severity < SeverityDeprecation {
-		report.Severity = SeverityDeprecation
+// classifyCommit rates a single commit. Conventional commits are breaking
+// only when marked so with "!" or a footer; keywords are a fallback for
+// repositories that do not follow the convention. Deprecations have no
+// structured marker and are always found by keyword. User rules are
+// weighed together with the built-in finding.
+func (rs *RuleSet) classifyCommit(c github.Commit, conventionalRepo bool) (CommitMessage, Severity, []Finding) {
+	msg := c.Commit.Message
+	cm := commitMessage(c)
+	if rs.excluded(msg, nil) {
+		return cm, SeverityOK, nil
+	}
+	cc, ok := conventional.Parse(msg)
+	var footer conventional.Footer
+	var hasFooter bool
+	if ok {
+		cm.Type, cm.Scope = cc.Type, cc.Scope
+		footer, hasFooter = cc.BreakingFooter()
+		cm.Note = strings.SplitN(footer.Value, "\n", 2)[0]
 	}
 
-	return report
+	var f Finding
+	switch {
+	case hasFooter:
+		f = Finding{Severity: SeverityBreaking, Rule: ruleFooter, Text: strings.TrimSpace(footer.Token + ": " + cm.Note), End: len(footer.Token)}
+	case ok && cc.Bang:
+		f = Finding{Severity: SeverityBreaking, Rule: ruleBang, Text: cm.Message, End: strings.Index(cm.Message, ":")}
+	case rs.NoBuiltin:
+	default:
+		var found bool
+		if !ok && !conventionalRepo {
+			if f, found = matchFinding(breakingRe, msg); found {
+				f.Severity, f.Rule = SeverityBreaking, ruleKeyword
+			}
+		}
+		if !found {
+			if f, found = matchFinding(deprecRe, msg); found {
+				f.Severity, f.Rule = SeverityDeprecation, ruleDeprecation
+			}
+		}
+	}
+
+	var candidates []Finding
+	if f.Severity != SeverityOK {
+		f.Source, f.Weight = SourceCommit, 1
+		candidates = append(candidates, f)
+	}
+	candidates = append(candidates, rs.match(SourceCommit, msg)...)
+	for i := range candidates {
+		candidates[i].Ref, candidates[i].URL = c.SHA, c.HTMLURL
+	}
+
+	sev, findings := rate(candidates)
+	if len(findings) > 0 {
+		cm.Signal = findings[0].Rule
+	}
+	return cm, sev, findings
+}
+
+// commitMessage keeps the first line of c's message.
+func commitMessage(c github.Commit) CommitMessage {
+	return CommitMessage{
+		SHA:     c.SHA,
+		Message: strings.SplitN(c.Commit.Message, "\n", 2)[0],
+		URL:     c.HTMLURL,
+	}
 }
 
-func analyzeReleases(releases []github.Release, _ string) []ReleaseInfo {
+// analyzeReleases rates releases, newest first, and returns the findings
+// behind every rating above SeverityFeature.
+func (rs *RuleSet) analyzeReleases(releases []github.Release, _ string) ([]ReleaseInfo, []Finding) {
 	infos := make([]ReleaseInfo, 0, len(releases))
+	var findings []Finding
 
 	// Sort releases by published date, newest first
 	sort.Slice(releases, func(i, j int) bool {
@@ -146,28 +441,43 @@ func analyzeReleases(releases []github.Release, _ string) []ReleaseInfo {
 			URL:      r.HTMLURL,
 			Severity: SeverityFeature,
 		}
+		var candidates []Finding
+		found := func(f Finding, sev Severity, rule string) {
+			f.Source, f.Severity, f.Rule, f.Weight = SourceRelease, sev, rule, 1
+			candidates = append(candidates, f)
+		}
 
 		// Check for semver major bumps
-		if matches := semverRe.FindStringSubmatch(r.TagName); matches != nil {
-			major, _ := strconv.Atoi(matches[1])
+		if matches := semverRe.FindStringSubmatchIndex(r.TagName); matches != nil {
+			major, _ := strconv.Atoi(r.TagName[matches[2]:matches[3]])
 			if i > 0 && prevMajor >= 0 && major > prevMajor {
-				info.Severity = SeverityBreaking
+				found(Finding{Text: r.TagName, Start: matches[2], End: matches[3]}, SeverityBreaking, ruleMajorBump)
 			}
 			prevMajor = major
 		}
 
 		// Check release notes for breaking keywords
 		fullText := r.Name + " " + r.Body
-		if breakingRe.MatchString(fullText) {
-			info.Severity = SeverityBreaking
-		} else if deprecRe.MatchString(fullText) && info.Severity < SeverityDeprecation {
-			info.Severity = SeverityDeprecation
+		if !rs.NoBuiltin {
+			if f, ok := matchFinding(breakingRe, fullText); ok {
+				found(f, SeverityBreaking, ruleKeyword)
+			} else if f, ok := matchFinding(deprecRe, fullText); ok {
+				found(f, SeverityDeprecation, ruleDeprecation)
+			}
+		}
+		candidates = append(candidates, rs.match(SourceRelease, fullText)...)
+
+		sev, kept := rate(candidates)
+		for _, f := range kept {
+			f.Ref, f.URL = r.TagName, r.HTMLURL
+			findings = append(findings, f)
 		}
+		info.Severity = max(info.Severity, sev)
 
 		infos = append(infos, info)
 	}
 
-	return infos
+	return infos, findings
 }
 
 func SortReports(reports []PluginReport) {
@@ -1,40 +1,150 @@
 package detector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Giankrp/nvimgotrack/internal/forge"
 	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
 )
 
-func TestBreakingKeywords(t *testing.T) {
+// fakeSource serves canned compare results and releases keyed by repo name.
+// A compare keyed "repo@head" takes precedence for that head.
+type fakeSource struct {
+	compares map[string]*github.CompareResult
+	releases map[string][]github.Release
+	moved    map[string]string // repo → canonical full name
+	defaults map[string]string // repo → default branch
+	branches map[string][]string
+
+	// History: commits served by GetCommit, branch history for
+	// CommitBefore (newest first), and bases whose compare fails.
+	commits  map[string]github.Commit
+	history  []github.Commit
+	failBase map[string]bool
+
+	files      map[string][]string // sha → changed paths
+	truncated  map[string]bool     // shas whose file list is cut short
+	changelogs map[string]string   // repo → CHANGELOG.md at head
+
+	infoCalls atomic.Int32
+}
+
+func (f *fakeSource) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error) {
+	if f.failBase[base] {
+		return nil, fmt.Errorf("%w: %s...%s", github.ErrNotFound, base, head)
+	}
+	c, ok := f.compares[repo+"@"+head]
+	if !ok {
+		c, ok = f.compares[repo]
+	}
+	if !ok {
+		return nil, fmt.Errorf("not found: %s/%s", owner, repo)
+	}
+	return c, nil
+}
+
+func (f *fakeSource) GetReleases(ctx context.Context, owner, repo string) ([]github.Release, error) {
+	return f.releases[repo], nil
+}
+
+func (f *fakeSource) GetRepoInfo(ctx context.Context, owner, repo string) (*github.RepoInfo, error) {
+	f.infoCalls.Add(1)
+	info := &github.RepoInfo{FullName: owner + "/" + repo, DefaultBranch: f.defaults[repo]}
+	if fullName, ok := f.moved[repo]; ok {
+		info.FullName = fullName
+	}
+	return info, nil
+}
+
+func (f *fakeSource) HasBranch(ctx context.Context, owner, repo, branch string) (bool, error) {
+	return slices.Contains(f.branches[repo], branch), nil
+}
+
+func (f *fakeSource) GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, error) {
+	if c, ok := f.commits[sha]; ok {
+		return &c, nil
+	}
+	return nil, fmt.Errorf("%w: commit %s", github.ErrNotFound, sha)
+}
+
+func (f *fakeSource) GetFile(ctx context.Context, owner, repo, path, ref string) (*github.FileContent, error) {
+	if text, ok := f.changelogs[repo]; ok && path == "CHANGELOG.md" {
+		return &github.FileContent{Path: path, Content: text, HTMLURL: "changelog-url"}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", github.ErrNotFound, path)
+}
+
+func (f *fakeSource) CommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error) {
+	if f.truncated[sha] {
+		return f.files[sha], fmt.Errorf("%w: %s", github.ErrTruncated, sha)
+	}
+	return f.files[sha], nil
+}
+
+func (f *fakeSource) CommitBefore(ctx context.Context, owner, repo, branch string, until time.Time) (*github.Commit, error) {
+	for _, c := range f.history {
+		if !c.Commit.Author.Date.After(until) {
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no commit before %s", github.ErrNotFound, until)
+}
+
+func commits(msgs ...string) []github.Commit {
+	out := make([]github.Commit, len(msgs))
+	for i, m := range msgs {
+		out[i] = github.Commit{SHA: fmt.Sprintf("sha%d", i), Commit: github.CommitDetail{Message: m}}
+	}
+	return out
+}
+
+func TestClassifyCommit(t *testing.T) {
 	tests := []struct {
-		msg      string
-		breaking bool
-		deprec   bool
+		msg          string
+		conventional bool // the repository follows Conventional Commits
+		want         Severity
+		signal       string
 	}{
-		{"feat: add new feature", false, false},
-		{"fix: resolve issue", false, false},
-		{"feat!: breaking change in API", true, false},
-		{"BREAKING CHANGE: removed old function", true, false},
-		{"refactor: this is incompatible with old version", true, false},
-		{"chore: deprecated old module", false, true},
-		{"fix: removed deprecated functionality, will be removed soon", true, true},
-		{"docs: update migration required guide", true, false},
+		{"feat: add new feature", true, SeverityOK, ""},
+		{"fix: removed a typo", true, SeverityOK, ""},
+		{"fix: removed a typo", false, SeverityOK, ""},
+		{"feat!: breaking change in API", true, SeverityBreaking, ruleBang},
+		{"perf(render)!: drop the legacy renderer", false, SeverityBreaking, ruleBang},
+		{"refactor: new config\n\nBREAKING-CHANGE: setup() takes a table", true, SeverityBreaking, ruleFooter},
+		{"feat(api): x\n\nBody.\n\nRefs: #12\nBREAKING CHANGE: opts renamed", true, SeverityBreaking, ruleFooter},
+		{"chore: deprecated old module", true, SeverityDeprecation, ruleDeprecation},
+		{"this is incompatible with old version", false, SeverityBreaking, ruleKeyword},
+		{"this is incompatible with old version", true, SeverityOK, ""},
+		{"Merge pull request #7 from x/removed-api", true, SeverityOK, ""},
+		{"update migration required guide", false, SeverityBreaking, ruleKeyword},
 	}
 
 	for _, tt := range tests {
-		gotBreaking := breakingRe.MatchString(tt.msg) || featBangRe.MatchString(tt.msg)
-		gotDeprec := deprecRe.MatchString(tt.msg)
-
-		if gotBreaking != tt.breaking {
-			t.Errorf("breaking(%q) = %v, want %v", tt.msg, gotBreaking, tt.breaking)
-		}
-		if gotDeprec != tt.deprec {
-			t.Errorf("deprecated(%q) = %v, want %v", tt.msg, gotDeprec, tt.deprec)
+		c := github.Commit{SHA: "abc", Commit: github.CommitDetail{Message: tt.msg}}
+		cm, sev, _ := new(RuleSet).classifyCommit(c, tt.conventional)
+		if sev != tt.want || cm.Signal != tt.signal {
+			t.Errorf("classifyCommit(%q, %v) = %v %q, want %v %q", tt.msg, tt.conventional, sev.Name(), cm.Signal, tt.want.Name(), tt.signal)
 		}
 	}
 }
 
+func TestUsesConventionalCommits(t *testing.T) {
+	if !usesConventionalCommits(commits("feat: a", "fix(ui): b", "Merge branch 'main'")) {
+		t.Error("mostly conventional history not detected")
+	}
+	if usesConventionalCommits(commits("Add a", "Fix b", "feat: c")) {
+		t.Error("free-form history detected as conventional")
+	}
+}
+
 func TestAnalyzeReleasesSeverity(t *testing.T) {
 	releases := []github.Release{
 		{TagName: "v2.0.0", Name: "BREAKING: Major rewrite", Body: "This is a breaking release"},
@@ -42,7 +152,7 @@ func TestAnalyzeReleasesSeverity(t *testing.T) {
 		{TagName: "v1.4.0", Name: "Deprecation notice", Body: "This API is deprecated and will change soon"},
 	}
 
-	infos := analyzeReleases(releases, "abc123")
+	infos, findings := new(RuleSet).analyzeReleases(releases, "abc123")
 
 	var foundBreaking, foundDeprecated bool
 	for _, info := range infos {
@@ -60,6 +170,41 @@ func TestAnalyzeReleasesSeverity(t *testing.T) {
 	if !foundDeprecated {
 		t.Error("expected v1.4.0 to be classified as deprecation")
 	}
+
+	want := []struct{ ref, rule, match string }{
+		{"v2.0.0", ruleKeyword, "BREAKING"},
+		{"v1.4.0", ruleDeprecation, "Deprecation"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.Source != SourceRelease || f.Ref != w.ref || f.Rule != w.rule || f.Match() != w.match {
+			t.Errorf("finding %d = %s %s %s %q, want release %s %s %q", i, f.Source, f.Ref, f.Rule, f.Match(), w.ref, w.rule, w.match)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("a ", 100) + "removed" + strings.Repeat(" b", 100)
+	tests := []struct {
+		text  string
+		match string
+		want  string
+	}{
+		{"first\nthe API was removed\nlast", "removed", "the API was removed"},
+		{"removed", "removed", "removed"},
+		{long, "removed", long[200-56 : 200+64]},
+		{"intro " + strings.Repeat("x", 150) + " outro", strings.Repeat("x", 150), strings.Repeat("x", 150)},
+	}
+	for _, tt := range tests {
+		start := strings.Index(tt.text, tt.match)
+		line, s, e := excerpt(tt.text, start, start+len(tt.match))
+		if line != tt.want || line[s:e] != tt.match {
+			t.Errorf("excerpt(%q) = %q [%d:%d], want %q", tt.text, line, s, e, tt.want)
+		}
+	}
 }
 
 func TestSeverityString(t *testing.T) {
@@ -73,3 +218,468 @@ func TestSeverityString(t *testing.T) {
 		t.Errorf("ok icon: got %s", SeverityOK.Icon())
 	}
 }
+
+func TestAnalyzeAllEmpty(t *testing.T) {
+	events := AnalyzeAll(t.Context(), forge.NewRegistry(), nil, 4, 0)
+	for ev := range events {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestAnalyzeAllKeepsLockfileOrder(t *testing.T) {
+	src := &fakeSource{compares: map[string]*github.CompareResult{
+		"a": {TotalCommits: 1, Commits: commits("feat!: drop old API")},
+		"b": {TotalCommits: 0},
+		"c": {TotalCommits: 2, Commits: commits("fix: typo", "chore: deprecated setup()")},
+	}}
+	plugins := []parser.Plugin{{Name: "a", Repo: "a"}, {Name: "b", Repo: "b"}, {Name: "c", Repo: "c"}, {Name: "d", Repo: "d"}}
+
+	reports := make([]PluginReport, len(plugins))
+	started := 0
+	for ev := range AnalyzeAll(t.Context(), forge.Single(src), plugins, 3, 0) {
+		if ev.Started {
+			started++
+			continue
+		}
+		reports[ev.Index] = ev.Report
+	}
+
+	if started != len(plugins) {
+		t.Errorf("started events: got %d, want %d", started, len(plugins))
+	}
+	want := []Severity{SeverityBreaking, SeverityOK, SeverityDeprecation, SeverityOK}
+	for i, r := range reports {
+		if r.Plugin.Name != plugins[i].Name {
+			t.Errorf("report %d is for %s, want %s", i, r.Plugin.Name, plugins[i].Name)
+		}
+		if r.Severity != want[i] {
+			t.Errorf("%s: severity %v, want %v", r.Plugin.Name, r.Severity, want[i])
+		}
+	}
+	if reports[3].Error == "" {
+		t.Error("expected an error for the missing repo")
+	}
+	if got := reports[0].BreakingMsgs[0].SHA; got != "sha0" {
+		t.Errorf("breaking commit SHA: got %q, want sha0", got)
+	}
+}
+
+func TestAnalyzeAllRoutesByHost(t *testing.T) {
+	gh := &fakeSource{compares: map[string]*github.CompareResult{"a": {TotalCommits: 1, Commits: commits("feat: x")}}}
+	gl := &fakeSource{compares: map[string]*github.CompareResult{"b": {TotalCommits: 1, Commits: commits("feat!: y")}}}
+	forges := forge.NewRegistry()
+	forges.Register("github.com", gh)
+	forges.Register("gitlab.com", gl)
+
+	plugins := []parser.Plugin{
+		{Name: "a", Host: "github.com", Repo: "a"},
+		{Name: "b", Host: "gitlab.com", Repo: "b"},
+		{Name: "c", Host: "git.sr.ht", Repo: "c"},
+	}
+	reports := make([]PluginReport, len(plugins))
+	for ev := range AnalyzeAll(t.Context(), forges, plugins, 2, 0) {
+		if !ev.Started {
+			reports[ev.Index] = ev.Report
+		}
+	}
+
+	if reports[0].Severity != SeverityFeature || reports[0].Error != "" {
+		t.Errorf("github plugin: %+v", reports[0])
+	}
+	if reports[1].Severity != SeverityBreaking || reports[1].Error != "" {
+		t.Errorf("gitlab plugin: %+v", reports[1])
+	}
+	if reports[2].Error == "" {
+		t.Error("expected an error for a host without a forge")
+	}
+}
+
+func TestAnalyzeAllDefaultBranch(t *testing.T) {
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{"a@main": {TotalCommits: 1, Commits: commits("feat: x")}},
+		defaults: map[string]string{"a": "main"},
+	}
+	plugins := []parser.Plugin{{Name: "a", Repo: "a", Commit: "abc"}}
+	for ev := range AnalyzeAll(t.Context(), forge.Single(src), plugins, 1, 0) {
+		if !ev.Started && (ev.Report.Error != "" || ev.Report.Severity != SeverityFeature) {
+			t.Errorf("report: %+v", ev.Report)
+		}
+	}
+	if n := src.infoCalls.Load(); n != 1 {
+		t.Errorf("GetRepoInfo calls: got %d, want 1", n)
+	}
+}
+
+func TestAnalyzeVersionConstraint(t *testing.T) {
+	all := commits("feat: new option", "feat!: drop nvim 0.9", "fix: typo", "refactor!: rename setup()")
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{
+			"p":        {TotalCommits: 4, Commits: all},
+			"p@v1.3.0": {TotalCommits: 1, Commits: all[:1], HTMLURL: "to-v1.3.0"},
+		},
+		releases: map[string][]github.Release{
+			"p": {
+				{TagName: "v2.0.0", Body: "BREAKING: new config"},
+				{TagName: "v1.3.0"},
+				{TagName: "v1.2.0"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		plugin   parser.Plugin
+		target   string
+		behind   int
+		beyond   int
+		severity Severity
+	}{
+		{"branch", parser.Plugin{Repo: "p"}, "", 4, 0, SeverityBreaking},
+		{"caret", parser.Plugin{Repo: "p", Version: "^1"}, "v1.3.0", 1, 3, SeverityFeature},
+		{"tag", parser.Plugin{Repo: "p", Tag: "v1.3.0"}, "v1.3.0", 1, 3, SeverityFeature},
+		{"no match", parser.Plugin{Repo: "p", Commit: "locked", Version: "^3"}, "locked", 0, 4, SeverityOK},
+		{"pin", parser.Plugin{Repo: "p", Commit: "locked", Pin: true, Version: "*"}, "locked", 0, 4, SeverityOK},
+		{"spec commit", parser.Plugin{Repo: "p", Commit: "locked", SpecCommit: "locked"}, "locked", 0, 4, SeverityOK},
+	}
+	for _, tt := range tests {
+		r := Analyze(t.Context(), src, tt.plugin)
+		if r.Error != "" {
+			t.Fatalf("%s: %s", tt.name, r.Error)
+		}
+		if r.Target != tt.target || r.BehindBy != tt.behind || r.BeyondBy != tt.beyond || r.Severity != tt.severity {
+			t.Errorf("%s: got target %q behind %d beyond %d severity %s", tt.name, r.Target, r.BehindBy, r.BeyondBy, r.Severity.Name())
+		}
+		if tt.target != "" && len(r.BeyondBreaking) != 2 {
+			t.Errorf("%s: expected 2 breaking commits beyond the target, got %v", tt.name, r.BeyondBreaking)
+		}
+	}
+
+	r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Version: "^1"})
+	for _, rel := range r.Releases {
+		if rel.BeyondRange != (rel.Tag == "v2.0.0") {
+			t.Errorf("%s: BeyondRange = %v, only v2.0.0 should be beyond ^1", rel.Tag, rel.BeyondRange)
+		}
+	}
+	if r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Version: "latest"}); r.Error == "" {
+		t.Error("expected an error for an invalid version constraint")
+	}
+}
+
+// taggedSource adds tag listing to a fakeSource.
+type taggedSource struct {
+	*fakeSource
+	tags []string
+	err  error
+}
+
+func (s *taggedSource) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	return s.tags, s.err
+}
+
+func TestAnalyzeVersionTags(t *testing.T) {
+	all := commits("feat: new option", "fix: typo", "feat!: drop nvim 0.9")
+	fake := &fakeSource{
+		compares: map[string]*github.CompareResult{
+			"p":        {TotalCommits: 3, Commits: all},
+			"p@v1.4.0": {TotalCommits: 2, Commits: all[:2], HTMLURL: "to-v1.4.0"},
+		},
+		releases: map[string][]github.Release{"p": {{TagName: "v2.0.0"}, {TagName: "v1.3.0"}}},
+	}
+
+	// v1.4.0 is tagged but has no release.
+	src := &taggedSource{fakeSource: fake, tags: []string{"v2.0.0", "v1.4.0", "v1.3.0", "nightly"}}
+	r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Version: "^1"})
+	if r.Error != "" {
+		t.Fatal(r.Error)
+	}
+	if r.Target != "v1.4.0" || r.BehindBy != 2 || r.CompareURL != "to-v1.4.0" {
+		t.Errorf("got target %q behind %d url %q, want v1.4.0 behind 2", r.Target, r.BehindBy, r.CompareURL)
+	}
+
+	src.err = errors.New("rate limited")
+	if r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Version: "^1"}); r.Error == "" || r.Target != "" {
+		t.Errorf("expected an error when tags cannot be listed, got target %q error %q", r.Target, r.Error)
+	}
+	if r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Tag: "v1.4.0"}); r.Error != "" {
+		t.Errorf("tags are only listed for version ranges, got %q", r.Error)
+	}
+}
+
+func TestAnalyzeTruncatedCompare(t *testing.T) {
+	src := &fakeSource{compares: map[string]*github.CompareResult{
+		"p": {TotalCommits: 2, Commits: commits("fix: a", "fix: b"), Truncated: true},
+	}}
+	r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Commit: "old"})
+	if r.Error != "" || !r.Partial || r.BehindBy != 2 {
+		t.Errorf("got partial %v behind %d error %q, want partial with the listed commits", r.Partial, r.BehindBy, r.Error)
+	}
+}
+
+func TestAnalyzeMovedRepo(t *testing.T) {
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{
+			"new.nvim":     {TotalCommits: 1, Commits: commits("feat: x")},
+			"renamed.nvim": {TotalCommits: 0},
+		},
+		moved: map[string]string{
+			"old.nvim":     "neworg/new.nvim",
+			"renamed.nvim": "Owner/renamed.nvim", // case only: not moved
+		},
+	}
+
+	r := Analyze(t.Context(), src, parser.Plugin{Name: "old.nvim", Owner: "someone", Repo: "old.nvim", SpecFile: "plugins.lua:3"})
+	if r.Error != "" {
+		t.Fatalf("expected the compare to be retried under the new name, got %s", r.Error)
+	}
+	if r.MovedTo != "neworg/new.nvim" || r.BehindBy != 1 {
+		t.Errorf("got moved to %q, behind %d", r.MovedTo, r.BehindBy)
+	}
+	want := `plugins.lua:3: replace "someone/old.nvim" with "neworg/new.nvim", adding name = "old.nvim"`
+	if r.SpecFix != want {
+		t.Errorf("spec fix:\n got %s\nwant %s", r.SpecFix, want)
+	}
+
+	r = Analyze(t.Context(), src, parser.Plugin{Name: "renamed.nvim", Owner: "owner", Repo: "renamed.nvim"})
+	if r.MovedTo != "" {
+		t.Errorf("a case-only difference is not a move, got %q", r.MovedTo)
+	}
+
+	fix := specFix(parser.Plugin{Name: "x", Host: "gitlab.com", Owner: "a", Repo: "x"}, "b/x")
+	if fix != `replace url = "https://gitlab.com/a/x" with url = "https://gitlab.com/b/x"` {
+		t.Errorf("gitlab spec fix: got %s", fix)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent, old := now.AddDate(0, -1, 0), now.AddDate(-2, 0, 0)
+
+	tests := []struct {
+		name     string
+		info     *github.RepoInfo
+		err      error
+		status   HealthStatus
+		days     int
+		mismatch bool
+	}{
+		{"active", &github.RepoInfo{DefaultBranch: "main", PushedAt: recent}, nil, HealthOK, 31, false},
+		{"stale", &github.RepoInfo{DefaultBranch: "main", PushedAt: old}, nil, HealthStale, 731, false},
+		{"archived", &github.RepoInfo{DefaultBranch: "main", PushedAt: recent, Archived: true}, nil, HealthArchived, 31, false},
+		{"renamed branch", &github.RepoInfo{DefaultBranch: "master", PushedAt: recent}, nil, HealthOK, 31, true},
+		{"not found", nil, fmt.Errorf("%w: https://api.github.com/repos/a/b", github.ErrNotFound), HealthNotFound, 0, false},
+		{"network error", nil, errors.New("request failed"), HealthOK, 0, false},
+	}
+	for _, tt := range tests {
+		r := PluginReport{Plugin: parser.Plugin{Branch: "main"}}
+		r.checkHealth(tt.info, tt.err, now)
+		h := r.Health
+		if h.Status != tt.status || h.DaysSincePush != tt.days || h.BranchMismatch != tt.mismatch {
+			t.Errorf("%s: got %+v", tt.name, h)
+		}
+	}
+
+	var s HealthStatus
+	if err := s.UnmarshalText([]byte("not_found")); err != nil || s != HealthNotFound {
+		t.Errorf("UnmarshalText: got %v, %v", s, err)
+	}
+}
+
+func TestAnalyzeBranchMismatch(t *testing.T) {
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{
+			"renamed@main":    {Status: "ahead", AheadBy: 2, TotalCommits: 2, Commits: commits("feat: a", "fix: b")},
+			"rewritten@main":  {Status: "diverged", AheadBy: 3, BehindBy: 4, TotalCommits: 3, Commits: commits("a", "b", "c")},
+			"feature@feature": {Status: "ahead", AheadBy: 1, TotalCommits: 1, Commits: commits("feat: c")},
+		},
+		defaults: map[string]string{"renamed": "main", "rewritten": "main", "feature": "main"},
+		branches: map[string][]string{"renamed": {"main"}, "feature": {"main", "feature"}},
+	}
+
+	tests := []struct {
+		repo, branch string
+		missing      bool
+		diverged     bool
+		behindBy     int
+		note         string
+	}{
+		{"renamed", "master", true, false, 2, "no longer exists"},
+		{"rewritten", "main", false, true, 3, "diverged: 4 locked"},
+		{"feature", "feature", false, false, 1, "not the default branch"},
+	}
+	for _, tt := range tests {
+		r := Analyze(t.Context(), src, parser.Plugin{Name: tt.repo, Repo: tt.repo, Branch: tt.branch, Commit: "abc"})
+		if r.Error != "" {
+			t.Errorf("%s: error %q", tt.repo, r.Error)
+			continue
+		}
+		if r.Health.BranchMissing != tt.missing || r.Diverged() != tt.diverged || r.BehindBy != tt.behindBy {
+			t.Errorf("%s: missing=%v diverged=%v behind=%d, want %v %v %d", tt.repo,
+				r.Health.BranchMissing, r.Diverged(), r.BehindBy, tt.missing, tt.diverged, tt.behindBy)
+		}
+		if !strings.Contains(r.BranchNote(), tt.note) {
+			t.Errorf("%s: BranchNote() = %q", tt.repo, r.BranchNote())
+		}
+	}
+}
+
+func TestAnalyzeRewrittenHistory(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	commitOn := func(sha string, d int) github.Commit {
+		return github.Commit{SHA: sha, Commit: github.CommitDetail{Author: github.CommitAuthor{Date: day(d)}}}
+	}
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{"p": {TotalCommits: 2, Commits: commits("feat: a", "feat: b")}},
+		releases: map[string][]github.Release{"p": {
+			{TagName: "v2.0.0", PublishedAt: day(20)},
+			{TagName: "v1.1.0", PublishedAt: day(8)},
+			{TagName: "v1.0.0", PublishedAt: day(1)},
+		}},
+		commits:  map[string]github.Commit{"dangling": commitOn("dangling", 10)},
+		history:  []github.Commit{commitOn("n3", 25), commitOn("n2", 9), commitOn("n1", 2)},
+		failBase: map[string]bool{"gone": true, "dangling": true, "unknown": true},
+	}
+	local := &fakeSource{commits: map[string]github.Commit{"gone": commitOn("gone", 5)}}
+
+	tests := []struct {
+		commit       string
+		nearest, tag string
+		wantErr      bool
+	}{
+		{"gone", "n1", "v1.0.0", false},     // dated by the local clone
+		{"dangling", "n2", "v1.1.0", false}, // still served, but off the branch
+		{"unknown", "", "", true},           // gone and undatable
+	}
+	for _, tt := range tests {
+		r := analyze(t.Context(), src, parser.Plugin{Name: "p", Repo: "p", Branch: "main", Commit: tt.commit}, nil, local)
+		if !r.Rewritten {
+			t.Errorf("%s: not marked rewritten", tt.commit)
+		}
+		if (r.Error != "") != tt.wantErr {
+			t.Errorf("%s: error %q, wantErr %v", tt.commit, r.Error, tt.wantErr)
+		}
+		if r.NearestCommit != tt.nearest || r.NearestTag != tt.tag {
+			t.Errorf("%s: nearest = %s (%s), want %s (%s)", tt.commit, r.NearestCommit, r.NearestTag, tt.nearest, tt.tag)
+		}
+		if !tt.wantErr && r.BehindBy != 2 {
+			t.Errorf("%s: BehindBy = %d, want 2", tt.commit, r.BehindBy)
+		}
+	}
+
+	// Only a missing commit in a repository that still exists is a
+	// rewritten history.
+	for name, r := range map[string]PluginReport{
+		"other error":    {Plugin: parser.Plugin{Repo: "p", Commit: "gone"}},
+		"repo not found": {Plugin: parser.Plugin{Repo: "p", Commit: "gone"}, Health: Health{Status: HealthNotFound}},
+	} {
+		compareErr := errors.New("request failed")
+		if name == "repo not found" {
+			compareErr = fmt.Errorf("%w: p", github.ErrNotFound)
+		}
+		if _, _, err := r.recoverRewritten(t.Context(), src, local, "", "p", "main", compareErr); err != compareErr || r.Rewritten {
+			t.Errorf("%s: got %v, rewritten %v; want the compare error", name, err, r.Rewritten)
+		}
+	}
+}
+
+// blockingSource answers nothing until the request's context is done.
+type blockingSource struct{ fakeSource }
+
+func (b *blockingSource) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAnalyzeAllDeadlines(t *testing.T) {
+	plugins := []parser.Plugin{{Name: "a", Repo: "a"}, {Name: "b", Repo: "b"}, {Name: "c", Repo: "c"}}
+	src := forge.Single(&blockingSource{})
+
+	// A per-plugin timeout fails each plugin on its own.
+	for ev := range AnalyzeAll(t.Context(), src, plugins, 2, 10*time.Millisecond) {
+		if !ev.Started && !strings.Contains(ev.Report.Error, "deadline exceeded") {
+			t.Errorf("%s: error %q, want a deadline error", ev.Report.Plugin.Name, ev.Report.Error)
+		}
+	}
+
+	// Canceling the run ends in-flight plugins and skips the rest, but
+	// still reports every plugin.
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	reports := 0
+	for ev := range AnalyzeAll(ctx, src, plugins, 1, 0) {
+		if ev.Started {
+			cancel()
+			continue
+		}
+		reports++
+		if !strings.Contains(ev.Report.Error, "canceled") {
+			t.Errorf("%s: error %q, want a cancellation", ev.Report.Plugin.Name, ev.Report.Error)
+		}
+	}
+	if reports != len(plugins) {
+		t.Errorf("got %d reports, want %d", reports, len(plugins))
+	}
+}
+
+func TestAnalyzeChangelog(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+	all := commits("feat: add picker", "chore(main): release 11.0.0")
+	base := github.Commit{SHA: "abc", Commit: github.CommitDetail{Author: github.CommitAuthor{Date: day(5)}}}
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{
+			"p":          {TotalCommits: 2, Commits: all, BaseCommit: base},
+			"p@v10.24.3": {TotalCommits: 0, BaseCommit: base},
+		},
+		releases: map[string][]github.Release{
+			"p": {
+				{TagName: "v11.0.0", PublishedAt: day(25)},
+				{TagName: "v10.24.3", PublishedAt: day(1)},
+			},
+		},
+		changelogs: map[string]string{"p": `# Changelog
+
+## [11.0.0](https://example.com/compare/v10.24.3...v11.0.0) (2024-06-25)
+
+### ⚠ BREAKING CHANGES
+
+* **spec:** removed the config alias ([abc1234](https://example.com/abc1234))
+
+## [10.24.3](https://example.com/compare/v10.24.2...v10.24.3) (2024-06-01)
+
+### ⚠ BREAKING CHANGES
+
+* already installed
+`},
+	}
+
+	r := Analyze(t.Context(), src, parser.Plugin{Repo: "p", Commit: "abc"})
+	if r.Severity != SeverityBreaking {
+		t.Fatalf("severity = %s, want breaking from the changelog", r.Severity.Name())
+	}
+	if len(r.Changelog) != 1 || r.Changelog[0].Version != "11.0.0" {
+		t.Fatalf("changelog = %+v, want only the 11.0.0 section", r.Changelog)
+	}
+	f := r.Findings[len(r.Findings)-1]
+	if f.Source != SourceChangelog || f.Ref != "11.0.0" || f.Match() != "⚠ BREAKING CHANGES" || f.URL != "changelog-url" {
+		t.Errorf("finding = %+v", f)
+	}
+
+	// Sections past the version constraint do not count.
+	r = Analyze(t.Context(), src, parser.Plugin{Repo: "p", Commit: "abc", Version: "^10"})
+	if r.Severity != SeverityOK || len(r.Changelog) != 0 {
+		t.Errorf("constrained: severity %s with changelog %+v, want ok and none", r.Severity.Name(), r.Changelog)
+	}
+
+	// Unreleased changes are listed apart and do not raise the severity.
+	// The locked commit is dated with GetCommit when the compare does not.
+	src.compares["q"] = &github.CompareResult{TotalCommits: 1, Commits: commits("feat: add picker")}
+	src.changelogs["q"] = "# Changelog\n\n## Unreleased\n\n### Breaking Changes\n\n* drop setup()\n"
+	r = Analyze(t.Context(), src, parser.Plugin{Repo: "q", Commit: "dated"})
+	if r.ChangelogNote == "" || r.Unreleased != nil {
+		t.Errorf("undated: note %q, unreleased %+v; want a note only", r.ChangelogNote, r.Unreleased)
+	}
+	src.commits = map[string]github.Commit{"dated": base}
+	r = Analyze(t.Context(), src, parser.Plugin{Repo: "q", Commit: "dated"})
+	if r.Severity != SeverityFeature || r.Unreleased == nil || len(r.Unreleased.Breaking) != 1 || r.ChangelogNote != "" {
+		t.Errorf("unreleased: severity %s, section %+v, note %q", r.Severity.Name(), r.Unreleased, r.ChangelogNote)
+	}
+}
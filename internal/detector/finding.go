@@ -0,0 +1,89 @@
+package detector
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Source values of a Finding.
+const (
+	SourceCommit    = "commit"
+	SourceRelease   = "release"
+	SourceChangelog = "changelog"
+)
+
+// Rules recorded in Finding.Rule. The commit ones double as
+// CommitMessage.Signal.
+const (
+	ruleFooter      = "BREAKING CHANGE footer"
+	ruleBang        = "! in header"
+	ruleKeyword     = "breaking keyword"
+	ruleDeprecation = "deprecation keyword"
+	ruleMajorBump   = "semver major bump"
+)
+
+// excerptLen bounds Finding.Text; longer lines are cut around the match.
+const excerptLen = 120
+
+// Finding is one signal that contributed to a report's Severity: which
+// rule matched where, and the matched span of Text.
+type Finding struct {
+	Source   string   `json:"source"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	// Text is the line the rule matched in; Start and End are byte
+	// offsets of the match within it.
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	// Ref is the commit SHA or release tag.
+	Ref string `json:"ref"`
+	URL string `json:"url,omitempty"`
+	// Weight is how much the finding counts toward Severity; see Rule.
+	Weight int `json:"weight"`
+}
+
+// Match returns the matched words.
+func (f Finding) Match() string {
+	if f.Start < 0 || f.End > len(f.Text) || f.Start >= f.End {
+		return ""
+	}
+	return f.Text[f.Start:f.End]
+}
+
+// matchFinding returns a finding for the first match of re in text, cut
+// down to the matching line, or false if re does not match.
+func matchFinding(re *regexp.Regexp, text string) (Finding, bool) {
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return Finding{}, false
+	}
+	line, start, end := excerpt(text, loc[0], loc[1])
+	return Finding{Text: line, Start: start, End: end}, true
+}
+
+// excerpt returns the line of text containing [start, end), shortened to
+// about excerptLen bytes around the match, and the match's offsets in it.
+func excerpt(text string, start, end int) (string, int, int) {
+	from := strings.LastIndexByte(text[:start], '\n') + 1
+	to := len(text)
+	if i := strings.IndexByte(text[end:], '\n'); i >= 0 {
+		to = end + i
+	}
+	if to-from > excerptLen {
+		// A match longer than excerptLen leaves no room around it, and
+		// the excerpt then starts at the match.
+		from = max(from, min(start, start-(excerptLen-(end-start))/2))
+		to = min(to, from+max(excerptLen, end-start))
+		from, to = runeBoundary(text, from), runeBoundary(text, to)
+	}
+	return text[from:to], start - from, end - from
+}
+
+// runeBoundary moves i back to the start of the UTF-8 sequence it is in.
+func runeBoundary(s string, i int) int {
+	for i > 0 && i < len(s) && s[i]&0xC0 == 0x80 {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,113 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// staleAfter is how long a repository may go without a push before it is
+// reported as unmaintained.
+const staleAfter = 365 * 24 * time.Hour
+
+// HealthStatus summarizes whether a plugin's repository is still
+// maintained. It is reported next to Severity rather than folded into it.
+type HealthStatus int
+
+const (
+	HealthOK HealthStatus = iota
+	HealthStale
+	HealthArchived
+	// HealthNotFound means the forge answered 404: the repository was
+	// deleted, made private, or the token cannot see it.
+	HealthNotFound
+)
+
+// Name returns the stable machine-readable name used in JSON reports.
+func (h HealthStatus) Name() string {
+	switch h {
+	case HealthNotFound:
+		return "not_found"
+	case HealthArchived:
+		return "archived"
+	case HealthStale:
+		return "stale"
+	default:
+		return "ok"
+	}
+}
+
+func (h HealthStatus) Icon() string {
+	switch h {
+	case HealthNotFound:
+		return "💀"
+	case HealthArchived:
+		return "🗄"
+	case HealthStale:
+		return "💤"
+	default:
+		return ""
+	}
+}
+
+func (h HealthStatus) MarshalText() ([]byte, error) {
+	return []byte(h.Name()), nil
+}
+
+func (h *HealthStatus) UnmarshalText(text []byte) error {
+	for s := HealthOK; s <= HealthNotFound; s++ {
+		if s.Name() == string(text) {
+			*h = s
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown health status %q", text)
+}
+
+// Health is the maintenance signal for a plugin's repository.
+type Health struct {
+	Status         HealthStatus `json:"status"`
+	Archived       bool         `json:"archived,omitempty"`
+	NotFound       bool         `json:"not_found,omitempty"`
+	LastPush       time.Time    `json:"last_push,omitzero"`
+	DaysSincePush  int          `json:"days_since_push,omitempty"`
+	DefaultBranch  string       `json:"default_branch,omitempty"`
+	BranchMismatch bool         `json:"branch_mismatch,omitempty"` // locked branch is not the default
+	BranchMissing  bool         `json:"branch_missing,omitempty"`  // locked branch was deleted upstream
+}
+
+// checkHealth fills r.Health from a GetRepoInfo result.
+func (r *PluginReport) checkHealth(info *github.RepoInfo, err error, now time.Time) {
+	h := &r.Health
+	if err != nil {
+		if errors.Is(err, github.ErrNotFound) {
+			h.NotFound = true
+			h.Status = HealthNotFound
+		}
+		return
+	}
+
+	h.Archived = info.Archived
+	h.DefaultBranch = info.DefaultBranch
+	h.BranchMismatch = branchMismatch(r.Plugin, info.DefaultBranch)
+	if !info.PushedAt.IsZero() {
+		h.LastPush = info.PushedAt
+		h.DaysSincePush = int(now.Sub(info.PushedAt) / (24 * time.Hour))
+	}
+
+	switch {
+	case h.Archived:
+		h.Status = HealthArchived
+	case !h.LastPush.IsZero() && now.Sub(h.LastPush) > staleAfter:
+		h.Status = HealthStale
+	}
+}
+
+// branchMismatch reports whether the locked branch is not the
+// repository's default one.
+func branchMismatch(p parser.Plugin, defaultBranch string) bool {
+	return p.Branch != "" && defaultBranch != "" && p.Branch != defaultBranch
+}
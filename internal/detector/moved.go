@@ -0,0 +1,52 @@
+package detector
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// checkMoved records the new location when info names a different
+// repository than the plugin was resolved to.
+func (r *PluginReport) checkMoved(info *github.RepoInfo) {
+	p := r.Plugin
+	current := p.Owner + "/" + p.Repo
+	if info.FullName == "" || strings.EqualFold(info.FullName, current) {
+		return
+	}
+	r.MovedTo = info.FullName
+	r.SpecFix = specFix(p, info.FullName)
+}
+
+// splitFullName splits "owner/repo", where owner may contain subgroups.
+func splitFullName(fullName string) (owner, repo string) {
+	i := strings.LastIndex(fullName, "/")
+	if i < 0 {
+		return "", fullName
+	}
+	return fullName[:i], fullName[i+1:]
+}
+
+// specFix suggests the change to the plugin's spec that points it at the
+// moved repository. When the repository name changed, `name` keeps the
+// plugin's directory and lockfile key.
+func specFix(p parser.Plugin, movedTo string) string {
+	source := func(fullName string) string {
+		if p.Host == "" || p.Host == parser.DefaultHost {
+			return fmt.Sprintf("%q", fullName)
+		}
+		return fmt.Sprintf("url = %q", "https://"+p.Host+"/"+fullName)
+	}
+
+	fix := fmt.Sprintf("replace %s with %s", source(p.Owner+"/"+p.Repo), source(movedTo))
+	if !strings.EqualFold(path.Base(movedTo), p.Name) {
+		fix += fmt.Sprintf(", adding name = %q", p.Name)
+	}
+	if p.SpecFile != "" {
+		fix = p.SpecFile + ": " + fix
+	}
+	return fix
+}
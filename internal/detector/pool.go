@@ -0,0 +1,135 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/forge"
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// Event reports the progress of AnalyzeAll for a single plugin. Each plugin
+// produces a Started event when a worker picks it up, followed by an event
+// carrying its Report.
+type Event struct {
+	Index   int
+	Started bool
+	Report  PluginReport
+}
+
+// prefetcher is implemented by sources that can load many repositories in
+// a single round trip before the per-plugin calls.
+type prefetcher interface {
+	Prefetch(ctx context.Context, refs []github.RepoRef) error
+}
+
+// Options configures AnalyzeAllWithOptions.
+type Options struct {
+	// Workers is the number of plugins analyzed concurrently.
+	Workers int
+	// PluginTimeout, if positive, bounds the analysis of each plugin.
+	PluginTimeout time.Duration
+	// Rules adds user detection rules to the built-in ones.
+	Rules *Rules
+	// Local, if set, dates locked commits that a force-push removed
+	// upstream.
+	Local CommitSource
+}
+
+// AnalyzeAll analyzes plugins using up to workers goroutines, sending each
+// plugin to the forge registered for its host. Events are sent on the
+// returned channel, which is closed once every plugin has been analyzed.
+// Index refers to the position in plugins, so callers can keep results in
+// lockfile order.
+//
+// Each plugin gets pluginTimeout, if positive, to finish. Once ctx is done,
+// in-flight requests are abandoned and the remaining plugins report the
+// cancellation as their error; callers must still drain the channel.
+func AnalyzeAll(ctx context.Context, forges *forge.Registry, plugins []parser.Plugin, workers int, pluginTimeout time.Duration) <-chan Event {
+	return AnalyzeAllWithOptions(ctx, forges, plugins, Options{Workers: workers, PluginTimeout: pluginTimeout})
+}
+
+// AnalyzeAllWithOptions is AnalyzeAll configured by opts.
+func AnalyzeAllWithOptions(ctx context.Context, forges *forge.Registry, plugins []parser.Plugin, opts Options) <-chan Event {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	events := make(chan Event)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(workers, max(len(plugins), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					events <- Event{Index: i, Report: PluginReport{Plugin: plugins[i], Error: fmt.Sprintf("canceled: %v", err)}}
+					continue
+				}
+				events <- Event{Index: i, Started: true}
+				events <- Event{Index: i, Report: analyzeWithTimeout(ctx, forges, plugins[i], opts)}
+			}
+		}()
+	}
+
+	go func() {
+		prefetch(ctx, forges, plugins)
+		for i := range plugins {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(events)
+	}()
+
+	return events
+}
+
+// analyzeWithTimeout runs analyzeRouted under its own deadline.
+func analyzeWithTimeout(ctx context.Context, forges *forge.Registry, plugin parser.Plugin, opts Options) PluginReport {
+	if opts.PluginTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.PluginTimeout)
+		defer cancel()
+	}
+	return analyzeRouted(ctx, forges, plugin, opts)
+}
+
+// analyzeRouted looks up the plugin's forge and analyzes it.
+func analyzeRouted(ctx context.Context, forges *forge.Registry, plugin parser.Plugin, opts Options) PluginReport {
+	f, err := forges.For(plugin.Host)
+	if err != nil {
+		return PluginReport{Plugin: plugin, Error: err.Error()}
+	}
+	return analyze(ctx, f, plugin, opts.Rules, opts.Local)
+}
+
+// prefetch gives every batching forge the plugins routed to it. A failed
+// prefetch is not fatal: each plugin is fetched on demand and reports its
+// own error.
+func prefetch(ctx context.Context, forges *forge.Registry, plugins []parser.Plugin) {
+	var order []prefetcher
+	refs := make(map[prefetcher][]github.RepoRef)
+	for _, p := range plugins {
+		f, err := forges.For(p.Host)
+		if err != nil {
+			continue
+		}
+		pf, ok := f.(prefetcher)
+		if !ok {
+			continue
+		}
+		if _, seen := refs[pf]; !seen {
+			order = append(order, pf)
+		}
+		refs[pf] = append(refs[pf], github.RepoRef{Owner: p.Owner, Repo: p.Repo, Base: p.Locked(), Head: p.Branch})
+	}
+	for _, pf := range order {
+		_ = pf.Prefetch(ctx, refs[pf])
+	}
+}
@@ -0,0 +1,110 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+)
+
+// historySource is implemented by sources that can look up single commits
+// and walk a branch by date, which places a locked commit that a
+// force-push removed upstream.
+type historySource interface {
+	GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, error)
+	CommitBefore(ctx context.Context, owner, repo, branch string, until time.Time) (*github.Commit, error)
+}
+
+// CommitSource looks up single commits. Options.Local reads locked
+// commits from the plugins' local clones, which keep them after upstream
+// rewrote its history; a *gitlocal.Backend implements it.
+type CommitSource interface {
+	GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, error)
+}
+
+// recoverRewritten handles a compare that failed because the locked commit
+// is no longer on head upstream, as after a force-push. It dates the
+// locked commit (from the forge if it still serves it, else from local),
+// finds the newest surviving commit and release made no later, and
+// compares from that commit instead. It returns the new base and compare,
+// or compareErr when the failure was something else.
+func (r *PluginReport) recoverRewritten(ctx context.Context, client Source, local CommitSource, owner, repo, head string, compareErr error) (string, *github.CompareResult, error) {
+	src, ok := client.(historySource)
+	if !ok || ctx.Err() != nil || !errors.Is(compareErr, github.ErrNotFound) || r.Health.Status == HealthNotFound {
+		return "", nil, compareErr
+	}
+
+	locked, err := src.GetCommit(ctx, owner, repo, r.Plugin.Locked())
+	gone := errors.Is(err, github.ErrNotFound)
+	switch {
+	case gone:
+		r.Rewritten = true
+		err = errors.New("no local clone")
+		if local != nil {
+			locked, err = local.GetCommit(ctx, r.Plugin.Owner, r.Plugin.Repo, r.Plugin.Locked())
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("locked commit %s no longer exists upstream and has no local clone to date it",
+				shortSHA(r.Plugin.Locked()))
+		}
+	case err != nil:
+		return "", nil, compareErr
+	}
+	date := locked.Commit.Author.Date
+
+	nearest, err := src.CommitBefore(ctx, owner, repo, head, date)
+	if err != nil {
+		if gone {
+			return "", nil, fmt.Errorf("locked commit %s no longer exists upstream: %w", shortSHA(r.Plugin.Locked()), err)
+		}
+		return "", nil, compareErr
+	}
+	compare, err := client.CompareCommits(ctx, owner, repo, nearest.SHA, head)
+	if err != nil {
+		return "", nil, compareErr
+	}
+
+	// The commit still exists but shares no history with head.
+	r.Rewritten = true
+	r.NearestCommit = nearest.SHA
+	if releases, err := client.GetReleases(ctx, owner, repo); err == nil {
+		r.NearestTag = releaseBefore(releases, date)
+	}
+	return nearest.SHA, compare, nil
+}
+
+// releaseBefore returns the tag of the newest release published no later
+// than date.
+func releaseBefore(releases []github.Release, date time.Time) string {
+	var tag string
+	var newest time.Time
+	for _, rel := range releases {
+		if rel.Draft || rel.PublishedAt.After(date) || rel.PublishedAt.Before(newest) {
+			continue
+		}
+		tag, newest = rel.TagName, rel.PublishedAt
+	}
+	return tag
+}
+
+// HistoryNote explains a rewritten history in one sentence, or returns "".
+func (r PluginReport) HistoryNote() string {
+	if !r.Rewritten {
+		return ""
+	}
+	note := fmt.Sprintf("history rewritten: locked commit %s is gone upstream, so :Lazy restore will fail",
+		shortSHA(r.Plugin.Locked()))
+	if r.NearestCommit != "" {
+		note += "; nearest surviving commit " + shortSHA(r.NearestCommit)
+	}
+	if r.NearestTag != "" {
+		note += " (tag " + r.NearestTag + ")"
+	}
+	return note
+}
+
+func shortSHA(sha string) string {
+	return sha[:min(12, len(sha))]
+}
@@ -0,0 +1,287 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// Rules is a user rules file. Its global rules apply to every plugin; the
+// ones under Plugins, keyed by plugin name or "owner/repo", add to them.
+type Rules struct {
+	RuleSet
+	Plugins map[string]RuleSet `json:"plugins,omitempty"`
+}
+
+// RuleSet is a list of detection rules and exclusions.
+type RuleSet struct {
+	Rules   []Rule      `json:"rules,omitempty"`
+	Exclude []Exclusion `json:"exclude,omitempty"`
+	// NoBuiltin turns off the built-in breaking and deprecation keywords.
+	// Conventional Commits markers and semver major bumps still count.
+	NoBuiltin bool `json:"no_builtin,omitempty"`
+}
+
+// Rule flags commit messages and release notes matching Pattern with
+// Severity. A commit or release is rated at the highest severity whose
+// findings' weights add up to at least 1, so a rule with a negative Weight
+// cancels a built-in match. Weight defaults to 1.
+type Rule struct {
+	Name     string   `json:"name,omitempty"`
+	Pattern  string   `json:"pattern"`
+	Severity Severity `json:"severity"`
+	Weight   int      `json:"weight,omitempty"`
+	// Source limits the rule to SourceCommit, SourceRelease or
+	// SourceChangelog text; empty matches all of them.
+	Source string `json:"source,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Exclusion drops commits whose message matches Message and whose changed
+// files all match Paths; either may be left out. A path ending in "/"
+// matches everything under that directory, one without a "/" matches file
+// names anywhere, and others are matched against the full path.
+type Exclusion struct {
+	Message string   `json:"message,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// fileSource is implemented by sources that can list the files a commit
+// changed, which path exclusions need.
+type fileSource interface {
+	CommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error)
+}
+
+// LoadRules reads a JSON rules file.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules: %w", err)
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// ParseRules decodes and compiles a rules file.
+func ParseRules(data []byte) (*Rules, error) {
+	var r Rules
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("parsing rules: %w", err)
+	}
+	if err := r.RuleSet.compile(); err != nil {
+		return nil, err
+	}
+	for name, rs := range r.Plugins {
+		if err := rs.compile(); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", name, err)
+		}
+		r.Plugins[name] = rs
+	}
+	return &r, nil
+}
+
+func (rs *RuleSet) compile() error {
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		if rule.Name == "" {
+			rule.Name = rule.Pattern
+		}
+		if rule.Pattern == "" {
+			return fmt.Errorf("rule %q: missing pattern", rule.Name)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		rule.re = re
+		if rule.Severity == SeverityOK {
+			return fmt.Errorf("rule %q: missing severity", rule.Name)
+		}
+		if rule.Weight == 0 {
+			rule.Weight = 1
+		}
+		switch rule.Source {
+		case "", SourceCommit, SourceRelease, SourceChangelog:
+		default:
+			return fmt.Errorf("rule %q: unknown source %q", rule.Name, rule.Source)
+		}
+	}
+	for i := range rs.Exclude {
+		ex := &rs.Exclude[i]
+		if ex.Message == "" && len(ex.Paths) == 0 {
+			return fmt.Errorf("exclusion %d: needs a message or paths", i+1)
+		}
+		if ex.Message != "" {
+			re, err := regexp.Compile(ex.Message)
+			if err != nil {
+				return fmt.Errorf("exclusion %d: %w", i+1, err)
+			}
+			ex.re = re
+		}
+	}
+	return nil
+}
+
+// For returns the rules that apply to p: the global ones followed by p's
+// own. A nil *Rules yields the built-in rules only.
+func (r *Rules) For(p parser.Plugin) *RuleSet {
+	if r == nil {
+		return &RuleSet{}
+	}
+	rs := RuleSet{
+		Rules:     slices.Clone(r.Rules),
+		Exclude:   slices.Clone(r.Exclude),
+		NoBuiltin: r.NoBuiltin,
+	}
+	keys := []string{p.Name, p.Owner + "/" + p.Repo}
+	for i, key := range keys {
+		own, ok := r.Plugins[key]
+		if !ok || slices.Contains(keys[:i], key) {
+			continue
+		}
+		rs.Rules = append(rs.Rules, own.Rules...)
+		rs.Exclude = append(rs.Exclude, own.Exclude...)
+		rs.NoBuiltin = rs.NoBuiltin || own.NoBuiltin
+	}
+	return &rs
+}
+
+// match returns a finding for every rule that matches text from source.
+func (rs *RuleSet) match(source, text string) []Finding {
+	var out []Finding
+	for _, rule := range rs.Rules {
+		if rule.Source != "" && rule.Source != source {
+			continue
+		}
+		if f, ok := matchFinding(rule.re, text); ok {
+			f.Source, f.Severity, f.Rule, f.Weight = source, rule.Severity, rule.Name, rule.Weight
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// excluded reports whether an exclusion drops a commit. Path exclusions
+// only apply when files is known.
+func (rs *RuleSet) excluded(msg string, files []string) bool {
+	for _, ex := range rs.Exclude {
+		if ex.re != nil && !ex.re.MatchString(msg) {
+			continue
+		}
+		if len(ex.Paths) == 0 {
+			return true
+		}
+		if len(files) > 0 && !slices.ContainsFunc(files, func(f string) bool { return !matchesAny(ex.Paths, f) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsFiles reports whether an exclusion that applies to msg looks at
+// changed files.
+func (rs *RuleSet) needsFiles(msg string) bool {
+	return slices.ContainsFunc(rs.Exclude, func(ex Exclusion) bool {
+		return len(ex.Paths) > 0 && (ex.re == nil || ex.re.MatchString(msg))
+	})
+}
+
+func matchesAny(patterns []string, file string) bool {
+	for _, p := range patterns {
+		var ok bool
+		switch {
+		case strings.HasSuffix(p, "/"):
+			ok = strings.HasPrefix(file, p)
+		case !strings.Contains(p, "/"):
+			ok, _ = path.Match(p, path.Base(file))
+		default:
+			ok, _ = path.Match(p, file)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rate picks the highest severity whose findings' weights add up to at
+// least 1, and returns it with the findings that count toward it.
+func rate(findings []Finding) (Severity, []Finding) {
+	for sev := SeverityBreaking; sev > SeverityOK; sev-- {
+		total := 0
+		for _, f := range findings {
+			if f.Severity == sev {
+				total += f.Weight
+			}
+		}
+		if total < 1 {
+			continue
+		}
+		var kept []Finding
+		for _, f := range findings {
+			if f.Severity == sev && f.Weight > 0 {
+				kept = append(kept, f)
+			}
+		}
+		return sev, kept
+	}
+	return SeverityOK, nil
+}
+
+// rateCommit classifies c and then applies path exclusions, which need
+// the commit's files from the forge. A file list that failed or was cut
+// short leaves the commit as rated.
+func (rs *RuleSet) rateCommit(ctx context.Context, client Source, owner, repo string, c github.Commit, conventionalRepo bool) (CommitMessage, Severity, []Finding) {
+	cm, sev, findings := rs.classifyCommit(c, conventionalRepo)
+	if sev == SeverityOK || !rs.needsFiles(c.Commit.Message) {
+		return cm, sev, findings
+	}
+	if src, ok := client.(fileSource); ok {
+		files, err := src.CommitFiles(ctx, owner, repo, c.SHA)
+		if err == nil && rs.excluded(c.Commit.Message, files) {
+			return cm, SeverityOK, nil
+		}
+	}
+	return cm, sev, findings
+}
+
+// Classification is how a commit message was rated.
+type Classification struct {
+	Message  string
+	Severity Severity
+	Findings []Finding
+}
+
+// ClassifyMessages rates commit messages the way Analyze rates the
+// commits of a repository with that history. Path exclusions are skipped,
+// since messages alone do not say which files changed.
+func (rs *RuleSet) ClassifyMessages(msgs []string) []Classification {
+	commits := make([]github.Commit, len(msgs))
+	for i, m := range msgs {
+		commits[i] = github.Commit{Commit: github.CommitDetail{Message: m}}
+	}
+	conventionalRepo := usesConventionalCommits(commits)
+	out := make([]Classification, len(msgs))
+	for i, c := range commits {
+		_, sev, findings := rs.classifyCommit(c, conventionalRepo)
+		out[i] = Classification{Message: msgs[i], Severity: sev, Findings: findings}
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+const testRules = `{
+	"rules": [
+		{"name": "bracket", "pattern": "\\[BREAKING\\]", "severity": "breaking"},
+		{"name": "typo", "pattern": "(?i)typo", "severity": "breaking", "weight": -1},
+		{"name": "alerta", "pattern": "(?i)obsoleto", "severity": "deprecation", "source": "release"}
+	],
+	"exclude": [
+		{"message": "^Revert "},
+		{"paths": ["doc/", "*.md"]}
+	],
+	"plugins": {
+		"folke/noice.nvim": {
+			"no_builtin": true,
+			"rules": [{"name": "boom", "pattern": "💥", "severity": "breaking"}]
+		}
+	}
+}`
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"rules": [{"pattern": "x"}]}`, "missing severity"},
+		{`{"rules": [{"pattern": "(", "severity": "breaking"}]}`, "missing closing )"},
+		{`{"rules": [{"pattern": "x", "severity": "huge"}]}`, "unknown severity"},
+		{`{"rules": [{"pattern": "x", "severity": "breaking", "source": "wiki"}]}`, "unknown source"},
+		{`{"exclude": [{}]}`, "needs a message or paths"},
+		{`{"plugins": {"a": {"rules": [{"severity": "breaking"}]}}}`, "plugin a"},
+		{`{"rule": []}`, "unknown field"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseRules([]byte(tt.json)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseRules(%s) error = %v, want %q", tt.json, err, tt.want)
+		}
+	}
+}
+
+func TestRulesClassifyMessages(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		plugin parser.Plugin
+		msg    string
+		want   Severity
+		rule   string
+	}{
+		{parser.Plugin{}, "[BREAKING] new config format", SeverityBreaking, "bracket"},
+		{parser.Plugin{}, "removed the old API", SeverityBreaking, ruleKeyword},
+		// The negative weight cancels the built-in keyword.
+		{parser.Plugin{}, "removed a typo", SeverityOK, ""},
+		{parser.Plugin{}, "Revert [BREAKING] change", SeverityOK, ""},
+		// Release-only rules do not apply to commits.
+		{parser.Plugin{}, "marcado como obsoleto", SeverityOK, ""},
+		{parser.Plugin{Owner: "folke", Repo: "noice.nvim"}, "removed the old API", SeverityOK, ""},
+		{parser.Plugin{Owner: "folke", Repo: "noice.nvim"}, "💥 new layout", SeverityBreaking, "boom"},
+	}
+	for _, tt := range tests {
+		got := rules.For(tt.plugin).ClassifyMessages([]string{tt.msg})[0]
+		rule := ""
+		for _, f := range got.Findings {
+			if f.Rule == tt.rule {
+				rule = f.Rule
+			}
+		}
+		if got.Severity != tt.want || rule != tt.rule {
+			t.Errorf("%s: %q = %v %q, want %v %q", tt.plugin.Repo, tt.msg, got.Severity.Name(), rule, tt.want.Name(), tt.rule)
+		}
+	}
+}
+
+func TestAnalyzeWithRules(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := &github.CompareResult{TotalCommits: 2, Commits: []github.Commit{
+		{SHA: "docs", Commit: github.CommitDetail{Message: "[BREAKING] rename in doc/setup.md"}},
+		{SHA: "code", Commit: github.CommitDetail{Message: "add the picker"}},
+	}}
+	src := &fakeSource{
+		compares: map[string]*github.CompareResult{"demo.nvim": cmp},
+		releases: map[string][]github.Release{"demo.nvim": {{TagName: "v1.1.0", Body: "`setup` queda obsoleto"}}},
+		files:    map[string][]string{"docs": {"doc/setup.md", "README.md"}},
+	}
+	plugin := parser.Plugin{Name: "demo.nvim", Owner: "someone", Repo: "demo.nvim", Branch: "main", Commit: "abc"}
+
+	r := AnalyzeWithRules(t.Context(), src, plugin, rules)
+	if r.Severity != SeverityDeprecation || len(r.BreakingMsgs) != 0 {
+		t.Fatalf("severity = %v with %d breaking commits, want deprecation from the release only", r.Severity.Name(), len(r.BreakingMsgs))
+	}
+	if len(r.Findings) != 1 || r.Findings[0].Rule != "alerta" || r.Findings[0].Match() != "obsoleto" {
+		t.Errorf("findings = %+v, want the release rule", r.Findings)
+	}
+
+	// A partial file list may hide files outside the excluded paths.
+	src.truncated = map[string]bool{"docs": true}
+	if r := AnalyzeWithRules(t.Context(), src, plugin, rules); r.Severity != SeverityBreaking {
+		t.Errorf("truncated files: severity = %v, want breaking", r.Severity.Name())
+	}
+	src.truncated = nil
+
+	// Once the commit touches code, the exclusion no longer applies.
+	src.files["docs"] = append(src.files["docs"], "lua/demo/init.lua")
+	if r := AnalyzeWithRules(t.Context(), src, plugin, rules); r.Severity != SeverityBreaking {
+		t.Errorf("severity = %v, want breaking", r.Severity.Name())
+	}
+}
+
+func TestRulesForPlugin(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "rules test -plugin owner/repo" names the plugin after its repository.
+	p := parser.Plugin{Name: "folke/noice.nvim", Owner: "folke", Repo: "noice.nvim"}
+	if rs := rules.For(p); len(rs.Rules) != len(rules.Rules)+1 {
+		t.Errorf("got %d rules, want the plugin's rule added once", len(rs.Rules))
+	}
+
+	rs, err := ParseRules([]byte(`{"exclude": [{"message": "^docs", "paths": ["doc/"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for msg, want := range map[string]bool{"docs: setup": true, "feat!: new layout": false} {
+		if got := rs.For(parser.Plugin{}).needsFiles(msg); got != want {
+			t.Errorf("needsFiles(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package forge abstracts the code hosting APIs plugins can live on, so
+// each plugin is analyzed against the API of the host it was cloned from.
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// Forge is the set of operations the detector needs from a code host.
+// Results use the GitHub-shaped types so every forge plugs into the same
+// analysis.
+type Forge interface {
+	CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error)
+	GetReleases(ctx context.Context, owner, repo string) ([]github.Release, error)
+	GetRepoInfo(ctx context.Context, owner, repo string) (*github.RepoInfo, error)
+}
+
+// Kind names a forge API flavor.
+type Kind string
+
+const (
+	KindGitHub Kind = "github"
+	KindGitLab Kind = "gitlab"
+	KindGitea  Kind = "gitea" // also Forgejo, e.g. codeberg.org
+)
+
+// ParseKind validates a forge kind name.
+func ParseKind(s string) (Kind, error) {
+	switch k := Kind(strings.ToLower(s)); k {
+	case KindGitHub, KindGitLab, KindGitea:
+		return k, nil
+	case "forgejo":
+		return KindGitea, nil
+	}
+	return "", fmt.Errorf("unknown forge kind %q: want github, gitlab or gitea", s)
+}
+
+// KnownHosts maps well-known public hosts to their forge kind.
+var KnownHosts = map[string]Kind{
+	"github.com":   KindGitHub,
+	"gitlab.com":   KindGitLab,
+	"codeberg.org": KindGitea,
+}
+
+// Registry routes each host to the Forge that serves it.
+type Registry struct {
+	forges   map[string]Forge
+	fallback Forge
+}
+
+func NewRegistry() *Registry {
+	return &Registry{forges: make(map[string]Forge)}
+}
+
+// Register sets the forge for host.
+func (r *Registry) Register(host string, f Forge) {
+	r.forges[strings.ToLower(host)] = f
+}
+
+// SetFallback sets the forge used for hosts without their own entry, such
+// as the local git backend, which works for any host.
+func (r *Registry) SetFallback(f Forge) {
+	r.fallback = f
+}
+
+// For returns the forge for host. An empty host means parser.DefaultHost.
+func (r *Registry) For(host string) (Forge, error) {
+	if host == "" {
+		host = parser.DefaultHost
+	}
+	if f, ok := r.forges[strings.ToLower(host)]; ok {
+		return f, nil
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("no forge configured for host %s", host)
+}
+
+// Single returns a registry that sends every host to f.
+func Single(f Forge) *Registry {
+	r := NewRegistry()
+	r.SetFallback(f)
+	return r
+}
+
+// ListTags reads at most maxTagPages pages of tags. GitLab serves up to
+// 100 entries per page; Gitea up to 50 unless its admin raised the limit.
+const (
+	maxTagPages    = 10
+	gitlabPageSize = 100
+	giteaPageSize  = 50
+)
+
+// getJSON performs an authenticated GET and decodes the JSON body.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, target any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "nvimgotrack/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", github.ErrNotFound, url)
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == 429 {
+		return fmt.Errorf("rate limited or forbidden: %s", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body[:min(200, len(body))]))
+	}
+	return json.Unmarshal(body, target)
+}
+
+// compareStatus names how head relates to base from the commits only
+// head has and those only base has, as GitHub's compare endpoint does.
+func compareStatus(ahead, behind int) string {
+	switch {
+	case ahead > 0 && behind > 0:
+		return "diverged"
+	case ahead > 0:
+		return "ahead"
+	case behind > 0:
+		return "behind"
+	default:
+		return "identical"
+	}
+}
+
+// hasParent reports whether base, a full or abbreviated SHA, is among
+// parents.
+func hasParent(parents []string, base string) bool {
+	for _, p := range parents {
+		if base != "" && strings.HasPrefix(p, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// branchExists maps the error of a single-branch lookup to HasBranch's
+// result: a 404 means the branch is gone.
+func branchExists(err error) (bool, error) {
+	if errors.Is(err, github.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 15 * time.Second}
+}
@@ -0,0 +1,167 @@
+package forge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// stub serves fixed JSON bodies keyed by request URI.
+func stub(t *testing.T, wantHeader, wantValue string, routes map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(wantHeader); got != wantValue {
+			t.Errorf("%s header: got %q, want %q", wantHeader, got, wantValue)
+		}
+		body, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitLab(t *testing.T) {
+	srv := stub(t, "PRIVATE-TOKEN", "glpat", map[string]string{
+		"/api/v4/projects/group%2Fsub%2Fplugin.nvim/repository/compare?from=abc&to=main": `{
+			"commits": [
+				{"id": "111", "message": "feat: one", "author_name": "a", "web_url": "https://gitlab.com/c/111", "parent_ids": ["abc0000"]},
+				{"id": "222", "message": "feat!: two", "author_name": "b", "parent_ids": ["111"]}
+			],
+			"web_url": "https://gitlab.com/group/sub/plugin.nvim/-/compare/abc...main"
+		}`,
+		"/api/v4/projects/group%2Fsub%2Fplugin.nvim/repository/compare?from=old&to=main": `{
+			"commits": [{"id": "333", "message": "fix: three", "parent_ids": ["base"]}]
+		}`,
+		"/api/v4/projects/group%2Fsub%2Fplugin.nvim/repository/compare?from=main&to=old": `{
+			"commits": [{"id": "old", "message": "wip", "parent_ids": ["base"]}, {"id": "444", "message": "wip"}]
+		}`,
+		"/api/v4/projects/group%2Fsub%2Fplugin.nvim/releases?per_page=30": `[
+			{"tag_name": "v1.0.0", "name": "One", "description": "notes", "commit": {"id": "111"}, "_links": {"self": "https://gitlab.com/r/v1"}}
+		]`,
+		"/api/v4/projects/group%2Fsub%2Fplugin.nvim/repository/tags?per_page=100&page=1": `[{"name": "v1.1.0"}, {"name": "v1.0.0"}]`,
+		"/api/v4/projects/group%2Fsub%2Fplugin.nvim": `{
+			"path_with_namespace": "group/sub/plugin.nvim", "default_branch": "main", "archived": true
+		}`,
+	})
+	g := NewGitLabWithBaseURL(srv.URL+"/api/v4", "glpat")
+
+	cmp, err := g.CompareCommits(t.Context(), "group/sub", "plugin.nvim", "abc", "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.TotalCommits != 2 || cmp.Commits[1].Commit.Message != "feat!: two" || cmp.Status != "ahead" || cmp.BehindBy != 0 {
+		t.Errorf("unexpected compare result: %+v", cmp)
+	}
+
+	// No listed commit descends from "old": the reverse compare counts
+	// the commits only it has.
+	cmp, err = g.CompareCommits(t.Context(), "group/sub", "plugin.nvim", "old", "main")
+	if err != nil || cmp.Status != "diverged" || cmp.AheadBy != 1 || cmp.BehindBy != 2 {
+		t.Errorf("diverged compare: got %+v, %v", cmp, err)
+	}
+
+	rels, err := g.GetReleases(t.Context(), "group/sub", "plugin.nvim")
+	if err != nil || len(rels) != 1 || rels[0].Body != "notes" || rels[0].TargetCommit != "111" {
+		t.Errorf("GetReleases: got %+v, %v", rels, err)
+	}
+
+	tags, err := g.ListTags(t.Context(), "group/sub", "plugin.nvim")
+	if err != nil || len(tags) != 2 || tags[0] != "v1.1.0" {
+		t.Errorf("ListTags: got %q, %v", tags, err)
+	}
+
+	info, err := g.GetRepoInfo(t.Context(), "group/sub", "plugin.nvim")
+	if err != nil || !info.Archived || info.DefaultBranch != "main" {
+		t.Errorf("GetRepoInfo: got %+v, %v", info, err)
+	}
+}
+
+func TestGitea(t *testing.T) {
+	srv := stub(t, "Authorization", "token cbtoken", map[string]string{
+		"/api/v1/repos/owner/plugin.nvim/compare/abc...main": `{
+			"total_commits": 1,
+			"commits": [{"sha": "333", "commit": {"message": "fix: x"}, "parents": [{"sha": "abc123"}]}]
+		}`,
+		"/api/v1/repos/owner/plugin.nvim/compare/main...main": `{"total_commits": 0, "commits": []}`,
+		"/api/v1/repos/owner/plugin.nvim/compare/new...main":  `{"total_commits": 0, "commits": []}`,
+		"/api/v1/repos/owner/plugin.nvim/compare/main...new": `{
+			"total_commits": 1,
+			"commits": [{"sha": "new", "commit": {"message": "fix: y"}}]
+		}`,
+		"/api/v1/repos/owner/plugin.nvim/releases?limit=30":    `[{"tag_name": "v0.1.0", "body": "first"}]`,
+		"/api/v1/repos/owner/plugin.nvim/tags?limit=50&page=1": `[{"name": "v0.2.0"}, {"name": "v0.1.0"}]`,
+		"/api/v1/repos/owner/plugin.nvim":                      `{"full_name": "owner/plugin.nvim", "default_branch": "main"}`,
+	})
+	g := NewGiteaWithBaseURL(srv.URL+"/api/v1", "cbtoken")
+
+	cmp, err := g.CompareCommits(t.Context(), "owner", "plugin.nvim", "abc", "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.TotalCommits != 1 || cmp.Commits[0].SHA != "333" || cmp.Status != "ahead" {
+		t.Errorf("unexpected compare result: %+v", cmp)
+	}
+	if cmp.HTMLURL != srv.URL+"/owner/plugin.nvim/compare/abc...main" {
+		t.Errorf("compare URL: got %q", cmp.HTMLURL)
+	}
+
+	for base, want := range map[string]string{"main": "identical", "new": "behind"} {
+		cmp, err := g.CompareCommits(t.Context(), "owner", "plugin.nvim", base, "main")
+		if err != nil || cmp.Status != want {
+			t.Errorf("compare from %s: got %+v, %v; want %s", base, cmp, err, want)
+		}
+	}
+
+	rels, err := g.GetReleases(t.Context(), "owner", "plugin.nvim")
+	if err != nil || len(rels) != 1 || rels[0].TagName != "v0.1.0" {
+		t.Errorf("GetReleases: got %+v, %v", rels, err)
+	}
+
+	tags, err := g.ListTags(t.Context(), "owner", "plugin.nvim")
+	if err != nil || len(tags) != 2 || tags[0] != "v0.2.0" {
+		t.Errorf("ListTags: got %q, %v", tags, err)
+	}
+
+	if _, err := g.GetRepoInfo(t.Context(), "owner", "missing"); err == nil {
+		t.Error("expected not found error")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	gh := NewGitea("example.invalid", "")
+	gl := NewGitLab("gitlab.com", "")
+
+	r := NewRegistry()
+	r.Register("github.com", gh)
+	r.Register("GitLab.com", gl)
+
+	if f, err := r.For(""); err != nil || f != Forge(gh) {
+		t.Errorf("empty host should map to %s, got %v, %v", parser.DefaultHost, f, err)
+	}
+	if f, err := r.For("gitlab.com"); err != nil || f != Forge(gl) {
+		t.Errorf("gitlab.com: got %v, %v", f, err)
+	}
+	if _, err := r.For("sr.ht"); err == nil {
+		t.Error("expected error for an unregistered host")
+	}
+
+	r.SetFallback(gl)
+	if f, err := r.For("sr.ht"); err != nil || f != Forge(gl) {
+		t.Errorf("fallback: got %v, %v", f, err)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if k, err := ParseKind("Forgejo"); err != nil || k != KindGitea {
+		t.Errorf("ParseKind(Forgejo) = %v, %v", k, err)
+	}
+	if _, err := ParseKind("svn"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
@@ -0,0 +1,167 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+)
+
+// Gitea talks to the Gitea/Forgejo REST API (v1), as used by codeberg.org.
+// Its compare and release payloads follow GitHub's field names.
+type Gitea struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewGitea returns a client for the Gitea or Forgejo instance at host.
+func NewGitea(host, token string) *Gitea {
+	return NewGiteaWithBaseURL("https://"+host+"/api/v1", token)
+}
+
+// NewGiteaWithBaseURL returns a client for an explicit API base URL.
+func NewGiteaWithBaseURL(baseURL, token string) *Gitea {
+	return &Gitea{httpClient: newHTTPClient(), baseURL: strings.TrimSuffix(baseURL, "/"), token: token}
+}
+
+type giteaRepo struct {
+	FullName      string    `json:"full_name"`
+	DefaultBranch string    `json:"default_branch"`
+	HTMLURL       string    `json:"html_url"`
+	Description   string    `json:"description"`
+	Archived      bool      `json:"archived"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type giteaCompare struct {
+	TotalCommits int           `json:"total_commits"`
+	Commits      []giteaCommit `json:"commits"`
+	HTMLURL      string        `json:"html_url"`
+}
+
+type giteaCommit struct {
+	github.Commit
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+func (c giteaCommit) parentSHAs() []string {
+	shas := make([]string, len(c.Parents))
+	for i, p := range c.Parents {
+		shas[i] = p.SHA
+	}
+	return shas
+}
+
+// CompareCommits lists the commits head has over its merge base with
+// base. Gitea does not report BehindBy: if a listed commit has base as a
+// parent, base is an ancestor and BehindBy is 0; otherwise a reverse
+// compare counts BehindBy.
+func (g *Gitea) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error) {
+	cmp, err := g.compare(ctx, owner, repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	result := github.CompareResult{
+		TotalCommits: max(cmp.TotalCommits, len(cmp.Commits)),
+		Commits:      make([]github.Commit, len(cmp.Commits)),
+		HTMLURL:      cmp.HTMLURL,
+	}
+	descends := false
+	for i, c := range cmp.Commits {
+		result.Commits[i] = c.Commit
+		descends = descends || hasParent(c.parentSHAs(), base)
+	}
+	result.AheadBy = result.TotalCommits
+	if !descends {
+		rev, err := g.compare(ctx, owner, repo, head, base)
+		if err != nil {
+			return nil, err
+		}
+		result.BehindBy = max(rev.TotalCommits, len(rev.Commits))
+	}
+	result.Status = compareStatus(result.AheadBy, result.BehindBy)
+	if result.HTMLURL == "" {
+		result.HTMLURL = fmt.Sprintf("%s/compare/%s...%s", g.webURL(owner, repo), base, head)
+	}
+	return &result, nil
+}
+
+func (g *Gitea) compare(ctx context.Context, owner, repo, base, head string) (*giteaCompare, error) {
+	var cmp giteaCompare
+	if err := g.get(ctx, fmt.Sprintf("%s/repos/%s/%s/compare/%s...%s", g.baseURL, owner, repo, base, head), &cmp); err != nil {
+		return nil, err
+	}
+	return &cmp, nil
+}
+
+func (g *Gitea) GetReleases(ctx context.Context, owner, repo string) ([]github.Release, error) {
+	var releases []github.Release
+	if err := g.get(ctx, fmt.Sprintf("%s/repos/%s/%s/releases?limit=30", g.baseURL, owner, repo), &releases); err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// ListTags returns the names of the repository's tags, newest first.
+func (g *Gitea) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	var names []string
+	for page := 1; page <= maxTagPages; page++ {
+		var tags []struct {
+			Name string `json:"name"`
+		}
+		u := fmt.Sprintf("%s/repos/%s/%s/tags?limit=%d&page=%d", g.baseURL, owner, repo, giteaPageSize, page)
+		if err := g.get(ctx, u, &tags); err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			names = append(names, t.Name)
+		}
+		if len(tags) < giteaPageSize {
+			break
+		}
+	}
+	return names, nil
+}
+
+func (g *Gitea) GetRepoInfo(ctx context.Context, owner, repo string) (*github.RepoInfo, error) {
+	var r giteaRepo
+	if err := g.get(ctx, fmt.Sprintf("%s/repos/%s/%s", g.baseURL, owner, repo), &r); err != nil {
+		return nil, err
+	}
+	return &github.RepoInfo{
+		FullName:      r.FullName,
+		DefaultBranch: r.DefaultBranch,
+		HTMLURL:       r.HTMLURL,
+		Description:   r.Description,
+		Archived:      r.Archived,
+		PushedAt:      r.UpdatedAt,
+	}, nil
+}
+
+// HasBranch reports whether branch exists in owner/repo.
+func (g *Gitea) HasBranch(ctx context.Context, owner, repo, branch string) (bool, error) {
+	var b struct {
+		Name string `json:"name"`
+	}
+	err := g.get(ctx, fmt.Sprintf("%s/repos/%s/%s/branches/%s", g.baseURL, owner, repo, branch), &b)
+	return branchExists(err)
+}
+
+// webURL derives the repository's web page from the API base URL.
+func (g *Gitea) webURL(owner, repo string) string {
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(g.baseURL, "/api/v1"), owner, repo)
+}
+
+func (g *Gitea) get(ctx context.Context, u string, target any) error {
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("Authorization", "token "+g.token)
+	}
+	return getJSON(ctx, g.httpClient, u, header, target)
+}
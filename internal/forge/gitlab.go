@@ -0,0 +1,225 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+)
+
+// GitLab talks to the GitLab REST API (v4). Owner may contain subgroups,
+// e.g. "group/subgroup".
+type GitLab struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewGitLab returns a client for the GitLab instance at host, e.g.
+// "gitlab.com".
+func NewGitLab(host, token string) *GitLab {
+	return NewGitLabWithBaseURL("https://"+host+"/api/v4", token)
+}
+
+// NewGitLabWithBaseURL returns a client for an explicit API base URL.
+func NewGitLabWithBaseURL(baseURL, token string) *GitLab {
+	return &GitLab{httpClient: newHTTPClient(), baseURL: strings.TrimSuffix(baseURL, "/"), token: token}
+}
+
+type gitlabCommit struct {
+	ID           string    `json:"id"`
+	Message      string    `json:"message"`
+	AuthorName   string    `json:"author_name"`
+	AuthoredDate time.Time `json:"authored_date"`
+	WebURL       string    `json:"web_url"`
+	ParentIDs    []string  `json:"parent_ids"`
+}
+
+func (c gitlabCommit) convert() github.Commit {
+	return github.Commit{
+		SHA:     c.ID,
+		HTMLURL: c.WebURL,
+		Commit: github.CommitDetail{
+			Message: c.Message,
+			Author:  github.CommitAuthor{Name: c.AuthorName, Date: c.AuthoredDate},
+		},
+	}
+}
+
+type gitlabCompare struct {
+	Commits []gitlabCommit `json:"commits"`
+	WebURL  string         `json:"web_url"`
+}
+
+type gitlabRelease struct {
+	TagName    string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	Desc       string    `json:"description"`
+	ReleasedAt time.Time `json:"released_at"`
+	Upcoming   bool      `json:"upcoming_release"`
+	Commit     struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Links struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+type gitlabProject struct {
+	PathWithNamespace string    `json:"path_with_namespace"`
+	DefaultBranch     string    `json:"default_branch"`
+	WebURL            string    `json:"web_url"`
+	Description       string    `json:"description"`
+	Archived          bool      `json:"archived"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+}
+
+// CompareCommits lists the commits head has over its merge base with
+// base. GitLab does not report BehindBy: if a listed commit has base as a
+// parent, base is an ancestor and BehindBy is 0; otherwise a reverse
+// compare counts BehindBy.
+func (g *GitLab) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error) {
+	cmp, err := g.compare(ctx, owner, repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]github.Commit, len(cmp.Commits))
+	descends := false
+	for i, c := range cmp.Commits {
+		commits[i] = c.convert()
+		descends = descends || hasParent(c.ParentIDs, base)
+	}
+	result := &github.CompareResult{
+		AheadBy:      len(commits),
+		TotalCommits: len(commits),
+		Commits:      commits,
+		HTMLURL:      cmp.WebURL,
+	}
+	if !descends {
+		rev, err := g.compare(ctx, owner, repo, head, base)
+		if err != nil {
+			return nil, err
+		}
+		result.BehindBy = len(rev.Commits)
+	}
+	result.Status = compareStatus(result.AheadBy, result.BehindBy)
+	return result, nil
+}
+
+func (g *GitLab) compare(ctx context.Context, owner, repo, from, to string) (*gitlabCompare, error) {
+	u := fmt.Sprintf("%s/repository/compare?from=%s&to=%s", g.projectURL(owner, repo), url.QueryEscape(from), url.QueryEscape(to))
+	var cmp gitlabCompare
+	if err := g.get(ctx, u, &cmp); err != nil {
+		return nil, err
+	}
+	return &cmp, nil
+}
+
+func (g *GitLab) GetReleases(ctx context.Context, owner, repo string) ([]github.Release, error) {
+	var rels []gitlabRelease
+	if err := g.get(ctx, g.projectURL(owner, repo)+"/releases?per_page=30", &rels); err != nil {
+		return nil, err
+	}
+	releases := make([]github.Release, len(rels))
+	for i, r := range rels {
+		releases[i] = github.Release{
+			TagName:      r.TagName,
+			Name:         r.Name,
+			Body:         r.Desc,
+			Prerelease:   r.Upcoming,
+			PublishedAt:  r.ReleasedAt,
+			HTMLURL:      r.Links.Self,
+			TargetCommit: r.Commit.ID,
+		}
+	}
+	return releases, nil
+}
+
+// ListTags returns the names of the project's tags, newest first.
+func (g *GitLab) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	var names []string
+	for page := 1; page <= maxTagPages; page++ {
+		var tags []struct {
+			Name string `json:"name"`
+		}
+		u := fmt.Sprintf("%s/repository/tags?per_page=%d&page=%d", g.projectURL(owner, repo), gitlabPageSize, page)
+		if err := g.get(ctx, u, &tags); err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			names = append(names, t.Name)
+		}
+		if len(tags) < gitlabPageSize {
+			break
+		}
+	}
+	return names, nil
+}
+
+func (g *GitLab) GetRepoInfo(ctx context.Context, owner, repo string) (*github.RepoInfo, error) {
+	var p gitlabProject
+	if err := g.get(ctx, g.projectURL(owner, repo), &p); err != nil {
+		return nil, err
+	}
+	return &github.RepoInfo{
+		FullName:      p.PathWithNamespace,
+		DefaultBranch: p.DefaultBranch,
+		HTMLURL:       p.WebURL,
+		Description:   p.Description,
+		Archived:      p.Archived,
+		PushedAt:      p.LastActivityAt,
+	}, nil
+}
+
+// GetCommit returns a single commit.
+func (g *GitLab) GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, error) {
+	var c gitlabCommit
+	if err := g.get(ctx, g.projectURL(owner, repo)+"/repository/commits/"+url.PathEscape(sha), &c); err != nil {
+		return nil, err
+	}
+	commit := c.convert()
+	return &commit, nil
+}
+
+// CommitBefore returns the newest commit on branch made no later than until.
+func (g *GitLab) CommitBefore(ctx context.Context, owner, repo, branch string, until time.Time) (*github.Commit, error) {
+	u := fmt.Sprintf("%s/repository/commits?ref_name=%s&until=%s&per_page=1",
+		g.projectURL(owner, repo), url.QueryEscape(branch), url.QueryEscape(until.UTC().Format(time.RFC3339)))
+	var commits []gitlabCommit
+	if err := g.get(ctx, u, &commits); err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: no commit on %s before %s", github.ErrNotFound, branch, until.Format(time.DateOnly))
+	}
+	commit := commits[0].convert()
+	return &commit, nil
+}
+
+// HasBranch reports whether branch exists in owner/repo.
+func (g *GitLab) HasBranch(ctx context.Context, owner, repo, branch string) (bool, error) {
+	var b struct {
+		Name string `json:"name"`
+	}
+	err := g.get(ctx, g.projectURL(owner, repo)+"/repository/branches/"+url.PathEscape(branch), &b)
+	return branchExists(err)
+}
+
+// projectURL is the project endpoint for owner/repo, addressed by its
+// URL-encoded path.
+func (g *GitLab) projectURL(owner, repo string) string {
+	return fmt.Sprintf("%s/projects/%s", g.baseURL, url.PathEscape(owner+"/"+repo))
+}
+
+func (g *GitLab) get(ctx context.Context, u string, target any) error {
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("PRIVATE-TOKEN", g.token)
+	}
+	return getJSON(ctx, g.httpClient, u, header, target)
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheEntry is a cached response body together with the validators needed
+// to revalidate it with a conditional request.
+type cacheEntry struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	FetchedAt    time.Time       `json:"fetched_at"`
+	Body         json.RawMessage `json:"body"`
+	// NotFound records a 404, for URLs cacheNotFound accepts.
+	NotFound bool `json:"not_found,omitempty"`
+}
+
+// cacheTTL returns how long a response for url is used without asking the
+// server. Releases, tags and repository metadata change rarely; compare results
+// against a branch head go stale with every upstream push.
+func cacheTTL(url string) time.Duration {
+	switch {
+	case strings.Contains(url, "/releases"), strings.Contains(url, "/tags?"):
+		return 12 * time.Hour
+	case strings.Contains(url, "/compare/"):
+		return 15 * time.Minute
+	default:
+		return 6 * time.Hour
+	}
+}
+
+// cacheNotFound reports whether a 404 for url is cached. Most plugins have
+// no CHANGELOG.md or NEWS.md, and each run would otherwise ask again for
+// both.
+func cacheNotFound(url string) bool {
+	return strings.Contains(url, "/contents/")
+}
+
+func (c *Client) readCache(url string) (*cacheEntry, error) {
+	data, err := os.ReadFile(c.cachePath(url))
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	// Files written before validators were stored hold a bare body.
+	if entry.FetchedAt.IsZero() || (len(entry.Body) == 0 && !entry.NotFound) {
+		return nil, fmt.Errorf("cache entry has no metadata")
+	}
+	return &entry, nil
+}
+
+func (c *Client) writeCache(url string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	path := c.cachePath(url)
+	dir := filepath.Dir(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = os.MkdirAll(dir, 0755)
+	_ = os.WriteFile(path, data, 0644)
+}
+
+// cachePath names the cache file for url after the SHA-256 of the full
+// URL, so that URLs differing only past a common prefix never share an
+// entry.
+func (c *Client) cachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:])+".json")
+}
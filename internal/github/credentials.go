@@ -0,0 +1,99 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials maps a forge host (e.g. "github.com", "ghe.example.com") to
+// its API token.
+type Credentials map[string]string
+
+// Token returns the token for host, or "" if there is none.
+func (c Credentials) Token(host string) string {
+	if host == "" {
+		host = "github.com"
+	}
+	return c[strings.ToLower(host)]
+}
+
+// Merge copies entries from other that c does not already have.
+func (c Credentials) Merge(other Credentials) {
+	for host, token := range other {
+		if _, ok := c[host]; !ok {
+			c[host] = token
+		}
+	}
+}
+
+// GHHostsPath returns the location of the gh CLI's hosts.yml:
+// $GH_CONFIG_DIR, then $XDG_CONFIG_HOME/gh, then ~/.config/gh.
+func GHHostsPath() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gh", "hosts.yml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gh", "hosts.yml")
+}
+
+// LoadGHHosts reads the oauth_token of every host in a gh hosts.yml file.
+// Only the small YAML subset gh writes is understood: top-level host keys
+// with an oauth_token directly below them, or under users.<name> for
+// multi-account configs. Hosts whose token lives in the system keyring
+// have no oauth_token and are skipped.
+func LoadGHHosts(path string) (Credentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	creds := make(Credentials)
+	userTokens := make(Credentials)
+	var host string
+	childIndent := 0 // indentation of the host's direct keys
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			host = strings.ToLower(strings.TrimSuffix(trimmed, ":"))
+			childIndent = 0
+			continue
+		}
+		if childIndent == 0 {
+			childIndent = indent(line)
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || key != "oauth_token" || host == "" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value == "" {
+			continue
+		}
+		if indent(line) == childIndent {
+			creds[host] = value
+		} else if _, ok := userTokens[host]; !ok {
+			userTokens[host] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	creds.Merge(userTokens)
+	return creds, nil
+}
+
+func indent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
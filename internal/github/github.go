@@ -1,144 +1,347 @@
 package github
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// DefaultAPIURL is the REST API base for github.com.
+const DefaultAPIURL = "https://api.github.com"
+
+// DefaultMaxCommits bounds how many commits CompareCommits pages through
+// when Options.MaxCommits is unset.
+const DefaultMaxCommits = 1000
+
+// comparePageSize is the largest page the compare endpoint serves.
+const comparePageSize = 100
+
+// tagPageSize is the largest page the tags endpoint serves, and
+// maxTagPages bounds how many of them ListTags reads.
+const (
+	tagPageSize = 100
+	maxTagPages = 10
+)
+
+// maxCommitFiles is the most files GitHub lists for a single commit.
+const maxCommitFiles = 300
+
+// ErrNotFound is wrapped by errors for repositories (and other resources)
+// the API reports as missing.
+var ErrNotFound = errors.New("not found")
+
+// ErrBranchNotFound is wrapped by errors for a compare against a branch
+// the repository does not have.
+var ErrBranchNotFound = errors.New("branch not found")
+
+// ErrTruncated is wrapped by errors for a file list the API cut short; the
+// partial list is returned with it.
+var ErrTruncated = errors.New("truncated")
+
+// APIURL returns the REST API base URL for a GitHub host. GitHub
+// Enterprise Server serves the API under /api/v3 on its own host.
+func APIURL(host string) string {
+	if host == "" || host == "github.com" {
+		return DefaultAPIURL
+	}
+	return "https://" + host + "/api/v3"
+}
+
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 	token      string
 	cacheDir   string
 	noCache    bool
+	refresh    bool
+	maxCommits int
+	retry      retryPolicy
 	mu         sync.Mutex
+	limit      rateLimit
+}
+
+// Options configures a Client.
+type Options struct {
+	Token string
+	// BaseURL is the REST API root; empty means DefaultAPIURL. See APIURL
+	// for GitHub Enterprise Server.
+	BaseURL string
+	// NoCache disables the on-disk response cache entirely.
+	NoCache bool
+	// Refresh revalidates every cached response with the server instead of
+	// trusting it until its TTL expires.
+	Refresh bool
+	// MaxCommits caps the commits CompareCommits collects; zero means
+	// DefaultMaxCommits.
+	MaxCommits int
+	// OnPause is called when requests stop to wait out a rate limit.
+	OnPause func(Pause)
 }
 
 func NewClient(token string, noCache bool) *Client {
+	return NewClientWithOptions(Options{Token: token, NoCache: noCache})
+}
+
+func NewClientWithOptions(opts Options) *Client {
 	cacheDir := ""
 	if home, err := os.UserHomeDir(); err == nil {
 		cacheDir = filepath.Join(home, ".cache", "nvimgotrack")
 	}
 
+	baseURL := strings.TrimSuffix(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+
+	maxCommits := opts.MaxCommits
+	if maxCommits <= 0 {
+		maxCommits = DefaultMaxCommits
+	}
+
 	return &Client{
 		httpClient: &http.Client{Timeout: 15 * time.Second},
-		token:      token,
+		baseURL:    baseURL,
+		token:      opts.Token,
 		cacheDir:   cacheDir,
-		noCache:    noCache,
+		noCache:    opts.NoCache,
+		refresh:    opts.Refresh,
+		maxCommits: maxCommits,
+		retry:      defaultRetry,
+		limit:      rateLimit{onPause: opts.OnPause},
 	}
 }
 
-func (c *Client) GetRepoInfo(owner, repo string) (*RepoInfo, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
+func (c *Client) GetRepoInfo(ctx context.Context, owner, repo string) (*RepoInfo, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s", c.baseURL, owner, repo)
 	var info RepoInfo
-	if err := c.get(url, &info); err != nil {
+	if err := c.get(ctx, url, &info); err != nil {
 		return nil, err
 	}
 	return &info, nil
 }
 
-func (c *Client) GetReleases(owner, repo string) ([]Release, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases?per_page=30", owner, repo)
+func (c *Client) GetReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=30", c.baseURL, owner, repo)
 	var releases []Release
-	if err := c.get(url, &releases); err != nil {
+	if err := c.get(ctx, url, &releases); err != nil {
 		return nil, err
 	}
 	return releases, nil
 }
 
-func (c *Client) CompareCommits(owner, repo, base, head string) (*CompareResult, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s", owner, repo, base, head)
-	var result CompareResult
-	if err := c.get(url, &result); err != nil {
-		return nil, err
+// ListTags returns the names of the repository's tags. Version ranges
+// resolve against tags: many plugins tag versions without publishing a
+// release for them.
+func (c *Client) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	var names []string
+	for page := 1; page <= maxTagPages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/tags?per_page=%d&page=%d", c.baseURL, owner, repo, tagPageSize, page)
+		var tags []struct {
+			Name string `json:"name"`
+		}
+		if err := c.get(ctx, url, &tags); err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			names = append(names, t.Name)
+		}
+		if len(tags) < tagPageSize {
+			break
+		}
 	}
-	return &result, nil
+	return names, nil
 }
 
-func (c *Client) get(url string, target any) error {
-	// Try cache first
-	if !c.noCache {
-		if data, err := c.readCache(url); err == nil {
-			return json.Unmarshal(data, target)
+// CompareCommits lists the commits from base to head, oldest first. An
+// unpaginated compare stops at 250 commits, so pages are followed until
+// TotalCommits or the client's MaxCommits is reached; a result with fewer
+// Commits than TotalCommits is partial.
+func (c *Client) CompareCommits(ctx context.Context, owner, repo, base, head string) (*CompareResult, error) {
+	var result CompareResult
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/compare/%s...%s?per_page=%d&page=%d",
+			c.baseURL, owner, repo, base, head, comparePageSize, page)
+		var p CompareResult
+		if err := c.get(ctx, url, &p); err != nil {
+			return nil, err
+		}
+		if page == 1 {
+			result = p
+		} else {
+			result.Commits = append(result.Commits, p.Commits...)
+		}
+		if len(p.Commits) < comparePageSize || len(result.Commits) >= min(result.TotalCommits, c.maxCommits) {
+			break
 		}
 	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("building request: %w", err)
-	}
-
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("User-Agent", "nvimgotrack/1.0")
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+	if len(result.Commits) > c.maxCommits {
+		result.Commits = result.Commits[:c.maxCommits]
 	}
+	return &result, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+// GetCommit returns a single commit. GitHub keeps serving commits that a
+// force-push removed from every branch until they are garbage collected.
+func (c *Client) GetCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, owner, repo, sha)
+	var commit Commit
+	if err := c.get(ctx, url, &commit); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &commit, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading response: %w", err)
+// CommitFiles lists the paths a commit changed. GitHub lists at most 300
+// files per commit; a list that long may be partial and comes with an
+// ErrTruncated error.
+func (c *Client) CommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, owner, repo, sha)
+	var commit struct {
+		Files []struct {
+			Filename string `json:"filename"`
+		} `json:"files"`
 	}
-
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("not found: %s", url)
+	if err := c.get(ctx, url, &commit); err != nil {
+		return nil, err
 	}
-	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == 429 {
-		return fmt.Errorf("rate limited — set GITHUB_TOKEN env var for higher limits")
+	files := make([]string, len(commit.Files))
+	for i, f := range commit.Files {
+		files[i] = f.Filename
 	}
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body[:min(200, len(body))]))
+	if len(files) >= maxCommitFiles {
+		return files, fmt.Errorf("%w: %s lists only its first %d files", ErrTruncated, sha, maxCommitFiles)
 	}
+	return files, nil
+}
 
-	if !c.noCache {
-		c.writeCache(url, body)
+// CommitBefore returns the newest commit on branch made no later than until.
+func (c *Client) CommitBefore(ctx context.Context, owner, repo, branch string, until time.Time) (*Commit, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits?sha=%s&until=%s&per_page=1",
+		c.baseURL, owner, repo, branch, until.UTC().Format(time.RFC3339))
+	var commits []Commit
+	if err := c.get(ctx, url, &commits); err != nil {
+		return nil, err
 	}
-
-	return json.Unmarshal(body, target)
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: no commit on %s before %s", ErrNotFound, branch, until.Format(time.DateOnly))
+	}
+	return &commits[0], nil
 }
 
-func (c *Client) readCache(url string) ([]byte, error) {
-	path := c.cachePath(url)
-	info, err := os.Stat(path)
-	if err != nil {
+// GetFile reads the file at path in owner/repo as of ref, decoding the
+// contents API's base64.
+func (c *Client) GetFile(ctx context.Context, owner, repo, path, ref string) (*FileContent, error) {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	u := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", c.baseURL, owner, repo, strings.Join(segments, "/"), url.QueryEscape(ref))
+	var file FileContent
+	if err := c.get(ctx, u, &file); err != nil {
 		return nil, err
 	}
-	if time.Since(info.ModTime()) > 1*time.Hour {
-		return nil, fmt.Errorf("cache expired")
+	if file.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		file.Content, file.Encoding = string(data), ""
 	}
-	return os.ReadFile(path)
+	return &file, nil
 }
 
-func (c *Client) writeCache(url string, data []byte) {
-	path := c.cachePath(url)
-	dir := filepath.Dir(path)
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	_ = os.MkdirAll(dir, 0755)
-	_ = os.WriteFile(path, data, 0644)
+// HasBranch reports whether branch exists in owner/repo.
+func (c *Client) HasBranch(ctx context.Context, owner, repo, branch string) (bool, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/branches/%s", c.baseURL, owner, repo, branch)
+	var b struct {
+		Name string `json:"name"`
+	}
+	if err := c.get(ctx, url, &b); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
 
-func (c *Client) cachePath(url string) string {
-	safe := ""
-	for _, r := range url {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
-			safe += string(r)
-		} else {
-			safe += "_"
+func (c *Client) get(ctx context.Context, url string, target any) error {
+	// Try cache first. Fresh entries are used as-is; stale ones are
+	// revalidated below, and a 304 does not count against the rate limit.
+	var cached *cacheEntry
+	if !c.noCache {
+		if entry, err := c.readCache(url); err == nil {
+			if !c.refresh && time.Since(entry.FetchedAt) < cacheTTL(url) {
+				if entry.NotFound {
+					return fmt.Errorf("%w: %s", ErrNotFound, url)
+				}
+				return json.Unmarshal(entry.Body, target)
+			}
+			if !entry.NotFound {
+				cached = entry
+			}
+		}
+	}
+
+	resp, body, err := c.retry.do(ctx, c.httpClient, &c.limit, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		req.Header.Set("User-Agent", "nvimgotrack/1.0")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.FetchedAt = time.Now()
+		c.writeCache(url, cached)
+		return json.Unmarshal(cached.Body, target)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		if !c.noCache && cacheNotFound(url) {
+			c.writeCache(url, &cacheEntry{FetchedAt: time.Now(), NotFound: true})
 		}
+		return fmt.Errorf("%w: %s", ErrNotFound, url)
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return statusError(resp, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body[:min(200, len(body))]))
 	}
-	if len(safe) > 200 {
-		safe = safe[:200]
+
+	if !c.noCache {
+		c.writeCache(url, &cacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+			Body:         body,
+		})
 	}
-	return filepath.Join(c.cacheDir, safe+".json")
+
+	return json.Unmarshal(body, target)
 }
@@ -0,0 +1,375 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitReservesQuota(t *testing.T) {
+	var l rateLimit
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", "2")
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	l.update(h)
+
+	l.acquire(t.Context())
+	l.acquire(t.Context())
+	if l.remaining != 0 {
+		t.Fatalf("remaining after two acquires: got %d, want 0", l.remaining)
+	}
+
+	// With the quota exhausted and a reset in the past, acquire must not block.
+	l.reset = time.Now().Add(-time.Second)
+	done := make(chan struct{})
+	go func() {
+		l.acquire(t.Context())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("acquire blocked after the rate-limit window reset")
+	}
+}
+
+func TestRateLimitIgnoresMissingHeaders(t *testing.T) {
+	var l rateLimit
+	l.update(http.Header{})
+	if l.known {
+		t.Error("expected quota to stay unknown without X-RateLimit headers")
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	var requests, revalidations int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"tag_name": "v1.0.0"}]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("", false)
+	c.cacheDir = t.TempDir()
+	url := srv.URL + "/repos/o/r/releases"
+
+	var releases []Release
+	if err := c.get(t.Context(), url, &releases); err != nil || len(releases) != 1 {
+		t.Fatalf("first get: %v, %+v", err, releases)
+	}
+
+	// Within the TTL the cached body is used without a request.
+	if err := c.get(t.Context(), url, &releases); err != nil {
+		t.Fatalf("cached get: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected cached response within TTL, got %d requests", requests)
+	}
+
+	// With refresh the entry is revalidated and the 304 reuses the body.
+	c.refresh = true
+	releases = nil
+	if err := c.get(t.Context(), url, &releases); err != nil {
+		t.Fatalf("revalidated get: %v", err)
+	}
+	if revalidations != 1 {
+		t.Errorf("expected one conditional request, got %d", revalidations)
+	}
+	if len(releases) != 1 || releases[0].TagName != "v1.0.0" {
+		t.Errorf("304 should reuse the cached body, got %+v", releases)
+	}
+}
+
+func TestCacheTTLPerEndpoint(t *testing.T) {
+	releases := cacheTTL("https://api.github.com/repos/o/r/releases?per_page=30")
+	compare := cacheTTL("https://api.github.com/repos/o/r/compare/abc...main")
+	if releases <= compare {
+		t.Errorf("releases TTL %v should be longer than compare TTL %v", releases, compare)
+	}
+}
+
+func TestCachePathUsesFullURL(t *testing.T) {
+	c := NewClient("", false)
+	c.cacheDir = t.TempDir()
+	prefix := "https://api.github.com/repos/o/r/contents/" + strings.Repeat("deep/", 50)
+	a, b := prefix+"CHANGELOG.md?ref=main", prefix+"NEWS.md?ref=main"
+	if c.cachePath(a) == c.cachePath(b) {
+		t.Fatalf("URLs sharing a %d-byte prefix map to the same cache file", len(prefix))
+	}
+	if c.cachePath(a) != c.cachePath(a) {
+		t.Error("cache path is not stable")
+	}
+
+	c.writeCache(a, &cacheEntry{FetchedAt: time.Now(), Body: []byte(`"a"`)})
+	c.writeCache(b, &cacheEntry{FetchedAt: time.Now(), Body: []byte(`"b"`)})
+	entry, err := c.readCache(a)
+	if err != nil || string(entry.Body) != `"a"` {
+		t.Errorf("read back %v, %v; want the entry written for a", entry, err)
+	}
+}
+
+func TestLoadGHHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.yml")
+	content := `github.com:
+    users:
+        alice:
+            oauth_token: gho_alice
+    git_protocol: https
+    oauth_token: gho_active
+    user: alice
+ghe.example.com:
+  users:
+    bob:
+      oauth_token: "ghe_bob"
+  user: bob
+keyring.example.com:
+    git_protocol: ssh
+    user: carol
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := LoadGHHosts(path)
+	if err != nil {
+		t.Fatalf("LoadGHHosts failed: %v", err)
+	}
+	if got := creds.Token("github.com"); got != "gho_active" {
+		t.Errorf("github.com: got %q, want the host-level token", got)
+	}
+	if got := creds.Token("GHE.example.com"); got != "ghe_bob" {
+		t.Errorf("ghe.example.com: got %q, want the user token", got)
+	}
+	if got := creds.Token("keyring.example.com"); got != "" {
+		t.Errorf("keyring host should have no token, got %q", got)
+	}
+}
+
+func TestClientUsesBaseURL(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"full_name": "corp/plugin.nvim"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(Options{BaseURL: srv.URL + "/api/v3/", NoCache: true})
+	info, err := c.GetRepoInfo(t.Context(), "corp", "plugin.nvim")
+	if err != nil {
+		t.Fatalf("GetRepoInfo failed: %v", err)
+	}
+	if gotPath != "/api/v3/repos/corp/plugin.nvim" || info.FullName != "corp/plugin.nvim" {
+		t.Errorf("got path %q, info %+v", gotPath, info)
+	}
+	if got := APIURL("ghe.example.com"); got != "https://ghe.example.com/api/v3" {
+		t.Errorf("APIURL: got %q", got)
+	}
+}
+
+func TestClientFollowsMovedRepo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("%s: authorization dropped on redirect", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/repos/old/name":
+			http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+		case "/repositories/42":
+			fmt.Fprint(w, `{"full_name": "new-org/new-name", "default_branch": "main"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(Options{Token: "tok", BaseURL: srv.URL, NoCache: true})
+	info, err := c.GetRepoInfo(t.Context(), "old", "name")
+	if err != nil || info.FullName != "new-org/new-name" {
+		t.Errorf("GetRepoInfo: got %+v, %v", info, err)
+	}
+}
+
+func TestCompareCommitsPaginates(t *testing.T) {
+	const total = 260
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var commits []string
+		for i := (page - 1) * perPage; i < min(page*perPage, total); i++ {
+			commits = append(commits, fmt.Sprintf(`{"sha": "c%d"}`, i))
+		}
+		fmt.Fprintf(w, `{"status": "ahead", "ahead_by": %d, "total_commits": %d, "commits": [%s]}`,
+			total, total, strings.Join(commits, ","))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		maxCommits, want int
+	}{
+		{0, total},
+		{150, 150},
+	}
+	for _, tt := range tests {
+		c := NewClientWithOptions(Options{BaseURL: srv.URL, NoCache: true, MaxCommits: tt.maxCommits})
+		cmp, err := c.CompareCommits(t.Context(), "o", "r", "base", "main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cmp.Commits) != tt.want || cmp.TotalCommits != total {
+			t.Errorf("max %d: got %d of %d commits, want %d", tt.maxCommits, len(cmp.Commits), cmp.TotalCommits, tt.want)
+		}
+		if cmp.Commits[0].SHA != "c0" {
+			t.Errorf("max %d: first commit %s, want c0", tt.maxCommits, cmp.Commits[0].SHA)
+		}
+	}
+}
+
+func TestGetRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		first     func(w http.ResponseWriter)
+		wantCalls int
+		wantErr   error
+		wantPause string
+	}{
+		{"server error", func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) }, 2, nil, ""},
+		{"secondary rate limit", func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+		}, 2, nil, "secondary rate limit"},
+		{"forbidden", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "Resource not accessible by personal access token"}`))
+		}, 1, ErrForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					tt.first(w)
+					return
+				}
+				_, _ = w.Write([]byte(`[]`))
+			}))
+			defer srv.Close()
+
+			var pauses []Pause
+			c := NewClientWithOptions(Options{BaseURL: srv.URL, NoCache: true, OnPause: func(p Pause) { pauses = append(pauses, p) }})
+			c.retry = retryPolicy{baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+
+			_, err := c.GetReleases(t.Context(), "o", "r")
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("made %d requests, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantPause == "" && len(pauses) > 0 || tt.wantPause != "" && (len(pauses) != 1 || pauses[0].Reason != tt.wantPause) {
+				t.Errorf("pauses = %+v, want one %q", pauses, tt.wantPause)
+			}
+		})
+	}
+}
+
+func TestRateLimitedUntil(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+		body   string
+		want   time.Duration
+		reason string
+		ok     bool
+	}{
+		{"primary", 403, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Unix()+600, 10)}, "", 601 * time.Second, "rate limit", true},
+		{"retry after", 429, map[string]string{"Retry-After": "30"}, "", 30 * time.Second, "secondary rate limit", true},
+		{"secondary body", 403, nil, `{"message": "You have exceeded a secondary rate limit"}`, secondaryLimitWait, "secondary rate limit", true},
+		{"private", 403, map[string]string{"X-RateLimit-Remaining": "4000"}, `{"message": "Must have admin rights"}`, 0, "", false},
+		{"not found", 404, nil, "", 0, "", false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		for k, v := range tt.header {
+			resp.Header.Set(k, v)
+		}
+		until, reason, ok := rateLimitedUntil(resp, []byte(tt.body), now)
+		if ok != tt.ok || reason != tt.reason || ok && until.Sub(now) != tt.want {
+			t.Errorf("%s: got %v %q %v, want +%v %q %v", tt.name, until.Sub(now), reason, ok, tt.want, tt.reason, tt.ok)
+		}
+	}
+}
+
+func TestGetFile(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/repos/o/r/contents/docs/NEWS 2.md" || r.URL.Query().Get("ref") != "release#1" {
+			http.NotFound(w, r)
+			return
+		}
+		// The contents API wraps base64 at 60 columns.
+		fmt.Fprint(w, `{"path": "docs/NEWS 2.md", "encoding": "base64", "content": "IyBDaGFuZ2Vs\nb2cK"}`)
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(Options{BaseURL: srv.URL})
+	c.cacheDir = t.TempDir()
+	f, err := c.GetFile(t.Context(), "o", "r", "docs/NEWS 2.md", "release#1")
+	if err != nil || f.Content != "# Changelog\n" {
+		t.Fatalf("GetFile = %+v, %v", f, err)
+	}
+
+	// A missing file is remembered, so the next run does not ask again.
+	for range 2 {
+		if _, err := c.GetFile(t.Context(), "o", "r", "NEWS.md", "main"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetFile(missing) error = %v, want ErrNotFound", err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests: got %d, want 2", n)
+	}
+}
+
+func TestListTagsPaginates(t *testing.T) {
+	const total = 130
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/repos/o/r/tags") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var tags []string
+		for i := (page - 1) * perPage; i < min(page*perPage, total); i++ {
+			tags = append(tags, fmt.Sprintf(`{"name": "v0.%d.0"}`, total-i))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(tags, ","))
+	}))
+	defer srv.Close()
+
+	c := NewClientWithOptions(Options{BaseURL: srv.URL, NoCache: true})
+	tags, err := c.ListTags(t.Context(), "o", "r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != total || tags[0] != "v0.130.0" || tags[total-1] != "v0.1.0" {
+		t.Errorf("got %d tags from %q to %q, want %d", len(tags), tags[0], tags[len(tags)-1], total)
+	}
+}
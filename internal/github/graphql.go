@@ -0,0 +1,673 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// graphQLBatchSize is how many repositories go into one aliased query.
+	// GitHub rejects queries whose estimated node count exceeds 500,000;
+	// 100 commits + 30 releases per repository stays well below that.
+	graphQLBatchSize = 25
+	// graphQLHistoryPage is the number of commits fetched per history page.
+	graphQLHistoryPage = 100
+	// graphQLMaxHistoryPages bounds the follow-up queries made when the
+	// pinned commit is not in the first page of history.
+	graphQLMaxHistoryPages = 5
+)
+
+// RepoRef identifies a repository and the commit range to fetch for it.
+// An empty Head means the repository's default branch.
+type RepoRef struct {
+	Owner string
+	Repo  string
+	Base  string
+	Head  string
+}
+
+func (r RepoRef) key() string {
+	return strings.ToLower(r.Owner + "/" + r.Repo)
+}
+
+// GraphQLClient fetches commit history, releases and repository metadata
+// through the GitHub GraphQL API. Prefetch loads many repositories in a
+// single aliased query; the REST-shaped methods then answer from memory and
+// only fall back to a one-repository query on a miss.
+type GraphQLClient struct {
+	httpClient *http.Client
+	endpoint   string
+	token      string
+	retry      retryPolicy
+	limit      rateLimit
+
+	mu sync.Mutex
+	// repos holds every range fetched for a repository, by key.
+	repos map[string][]*graphRepo
+}
+
+// graphRepo is everything fetched for one RepoRef.
+type graphRepo struct {
+	ref      RepoRef
+	info     RepoInfo
+	compare  *CompareResult
+	releases []Release
+	err      error
+	// headErr is set when the repository was found but its head ref was
+	// not; only CompareCommits fails.
+	headErr error
+}
+
+// GraphQLURL returns the GraphQL endpoint for a GitHub host. GitHub
+// Enterprise Server serves it at /api/graphql on its own host.
+func GraphQLURL(host string) string {
+	if host == "" || host == "github.com" {
+		return DefaultAPIURL + "/graphql"
+	}
+	return "https://" + host + "/api/graphql"
+}
+
+// GraphQLURLForAPI returns the GraphQL endpoint matching a REST API base
+// URL: GitHub Enterprise Server's /api/v3 maps to /api/graphql, and any
+// other base, such as api.github.com, serves it at <base>/graphql.
+func GraphQLURLForAPI(apiURL string) string {
+	base := strings.TrimSuffix(apiURL, "/")
+	if prefix, ok := strings.CutSuffix(base, "/api/v3"); ok {
+		return prefix + "/api/graphql"
+	}
+	return base + "/graphql"
+}
+
+// NewGraphQLClient returns a client for api.github.com. The GraphQL API
+// does not allow anonymous access, so token must be set.
+func NewGraphQLClient(token string) *GraphQLClient {
+	return NewGraphQLClientWithEndpoint(GraphQLURL(""), token)
+}
+
+// NewGraphQLClientWithEndpoint returns a client for an explicit GraphQL
+// endpoint, e.g. GraphQLURL of a GitHub Enterprise host.
+func NewGraphQLClientWithEndpoint(endpoint, token string) *GraphQLClient {
+	return NewGraphQLClientWithOptions(GraphQLOptions{Endpoint: endpoint, Token: token})
+}
+
+// GraphQLOptions configures a GraphQLClient.
+type GraphQLOptions struct {
+	// Endpoint is the GraphQL URL; empty means GraphQLURL("").
+	Endpoint string
+	Token    string
+	// OnPause is called when requests stop to wait out a rate limit.
+	OnPause func(Pause)
+}
+
+func NewGraphQLClientWithOptions(opts GraphQLOptions) *GraphQLClient {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = GraphQLURL("")
+	}
+	return &GraphQLClient{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		endpoint:   endpoint,
+		token:      opts.Token,
+		retry:      defaultRetry,
+		limit:      rateLimit{onPause: opts.OnPause},
+		repos:      make(map[string][]*graphRepo),
+	}
+}
+
+// Prefetch loads refs in batches of aliased queries. Per-repository
+// failures (e.g. not found) are recorded and returned by the later calls;
+// the returned error only reports failed round trips.
+func (c *GraphQLClient) Prefetch(ctx context.Context, refs []RepoRef) error {
+	var firstErr error
+	for start := 0; start < len(refs); start += graphQLBatchSize {
+		batch := refs[start:min(start+graphQLBatchSize, len(refs))]
+		if err := c.fetchBatch(ctx, batch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// CompareCommits lists the commits from base to head. A head that is not a
+// release tag is looked up as a branch first, then as a tag, so that spec
+// tags without a release resolve too.
+func (c *GraphQLClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (*CompareResult, error) {
+	if c.isTag(owner, repo, head) {
+		head = "refs/tags/" + head
+	}
+	r, err := c.lookup(ctx, RepoRef{Owner: owner, Repo: repo, Base: base, Head: head})
+	if err != nil {
+		return nil, err
+	}
+	if errors.Is(r.headErr, ErrBranchNotFound) && head != "" && !strings.HasPrefix(head, "refs/") {
+		tag, err := c.lookup(ctx, RepoRef{Owner: owner, Repo: repo, Base: base, Head: "refs/tags/" + head})
+		if err != nil {
+			return nil, err
+		}
+		if tag.headErr == nil {
+			return tag.compare, nil
+		}
+	}
+	if r.headErr != nil {
+		return nil, r.headErr
+	}
+	return r.compare, nil
+}
+
+// HasBranch reports whether branch exists in owner/repo. A prefetched
+// range on that branch answers it without another query.
+func (c *GraphQLClient) HasBranch(ctx context.Context, owner, repo, branch string) (bool, error) {
+	ref := RepoRef{Owner: owner, Repo: repo, Head: branch}
+	onBranch := func(r *graphRepo) bool { return r.ref.Head == branch }
+	r := c.find(ref, onBranch)
+	if r == nil {
+		if err := c.fetchBatch(ctx, []RepoRef{ref}); err != nil {
+			return false, err
+		}
+		r = c.find(ref, onBranch)
+	}
+	if r.err != nil {
+		return false, r.err
+	}
+	return r.headErr == nil, nil
+}
+
+func (c *GraphQLClient) GetReleases(ctx context.Context, owner, repo string) ([]Release, error) {
+	r, err := c.lookup(ctx, RepoRef{Owner: owner, Repo: repo})
+	if err != nil {
+		return nil, err
+	}
+	return r.releases, nil
+}
+
+// ListTags returns the names of the repository's tags, paging through
+// refs under refs/tags/. Tags are not prefetched: only plugins with a
+// version range need them.
+func (c *GraphQLClient) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	var names []string
+	vars := map[string]any{"owner": owner, "name": repo}
+	for page := 0; page < maxTagPages; page++ {
+		var out struct {
+			Data struct {
+				Repository *struct {
+					Refs struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []struct {
+							Name string `json:"name"`
+						} `json:"nodes"`
+					} `json:"refs"`
+				} `json:"repository"`
+			} `json:"data"`
+			Errors []gqlError `json:"errors"`
+		}
+		if err := c.post(ctx, graphTagsQuery, vars, &out); err != nil {
+			return nil, err
+		}
+		r := out.Data.Repository
+		if r == nil {
+			return nil, aliasError(out.Errors, "repository", RepoRef{Owner: owner, Repo: repo})
+		}
+		for _, n := range r.Refs.Nodes {
+			names = append(names, n.Name)
+		}
+		if !r.Refs.PageInfo.HasNextPage {
+			break
+		}
+		vars["after"] = r.Refs.PageInfo.EndCursor
+	}
+	return names, nil
+}
+
+func (c *GraphQLClient) GetRepoInfo(ctx context.Context, owner, repo string) (*RepoInfo, error) {
+	r, err := c.lookup(ctx, RepoRef{Owner: owner, Repo: repo})
+	if err != nil {
+		return nil, err
+	}
+	info := r.info
+	return &info, nil
+}
+
+// sameHead reports whether head names the ref r was fetched for. A fetch
+// without a head followed the default branch.
+func (r *graphRepo) sameHead(head string) bool {
+	if r.ref.Head == "" {
+		return head == "" || head == r.info.DefaultBranch
+	}
+	return head == r.ref.Head
+}
+
+// isTag reports whether head names a release tag already fetched for the
+// repository, so that CompareCommits can query it as a tag, not a branch.
+func (c *GraphQLClient) isTag(owner, repo, head string) bool {
+	if head == "" {
+		return false
+	}
+	return c.find(RepoRef{Owner: owner, Repo: repo}, func(r *graphRepo) bool {
+		return slices.ContainsFunc(r.releases, func(rel Release) bool { return rel.TagName == head })
+	}) != nil
+}
+
+// lookup returns the prefetched data for ref, fetching it on a miss. A ref
+// without Base matches any prefetched range for the same repository; one
+// with a Base only the range fetched from that commit to the same head.
+func (c *GraphQLClient) lookup(ctx context.Context, ref RepoRef) (*graphRepo, error) {
+	match := func(r *graphRepo) bool {
+		return ref.Base == "" || (r.ref.Base == ref.Base && r.sameHead(ref.Head))
+	}
+	r := c.find(ref, match)
+	if r == nil {
+		if err := c.fetchBatch(ctx, []RepoRef{ref}); err != nil {
+			return nil, err
+		}
+		r = c.find(ref, func(r *graphRepo) bool { return r.ref == ref })
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r, nil
+}
+
+// find returns the first range fetched for ref's repository that match
+// accepts, or nil.
+func (c *GraphQLClient) find(ref RepoRef, match func(*graphRepo) bool) *graphRepo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range c.repos[ref.key()] {
+		if match(r) {
+			return r
+		}
+	}
+	return nil
+}
+
+// ── Query building ───────────────────────────────────────────────────────
+
+const graphRepoFields = `
+    nameWithOwner
+    url
+    description
+    isArchived
+    pushedAt
+    defaultBranchRef { name }
+    head: %[1]s {
+      name
+      target {
+        ... on Commit { %[2]s }
+        ... on Tag { target { ... on Commit { %[2]s } } }
+      }
+    }
+    releases(first: 30, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes { tagName name description isDraft isPrerelease publishedAt url tagCommit { oid } }
+    }`
+
+const graphTagsQuery = `query($owner: String!, $name: String!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    refs(refPrefix: "refs/tags/", first: 100, after: $after, orderBy: {field: TAG_COMMIT_DATE, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes { name }
+    }
+  }
+}`
+
+const graphHistoryFields = `history(first: %d%s) {
+            pageInfo { hasNextPage endCursor }
+            nodes { oid message url author { name date } }
+          }`
+
+// buildQuery returns an aliased query for refs along with its variables.
+// cursors holds an optional history cursor per ref for follow-up pages.
+func buildQuery(refs []RepoRef, cursors []string) (string, map[string]any) {
+	var params, body strings.Builder
+	vars := make(map[string]any)
+
+	for i, ref := range refs {
+		owner, name := fmt.Sprintf("o%d", i), fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&params, "$%s: String!, $%s: String!, ", owner, name)
+		vars[owner] = ref.Owner
+		vars[name] = ref.Repo
+
+		head := "defaultBranchRef"
+		if ref.Head != "" {
+			branch := fmt.Sprintf("b%d", i)
+			fmt.Fprintf(&params, "$%s: String!, ", branch)
+			if strings.HasPrefix(ref.Head, "refs/") {
+				vars[branch] = ref.Head
+			} else {
+				vars[branch] = "refs/heads/" + ref.Head
+			}
+			head = fmt.Sprintf("ref(qualifiedName: $%s)", branch)
+		}
+
+		after := ""
+		if cursors != nil && cursors[i] != "" {
+			cursor := fmt.Sprintf("c%d", i)
+			fmt.Fprintf(&params, "$%s: String!, ", cursor)
+			vars[cursor] = cursors[i]
+			after = fmt.Sprintf(", after: $%s", cursor)
+		}
+
+		fmt.Fprintf(&body, "  r%d: repository(owner: $%s, name: $%s) {", i, owner, name)
+		history := fmt.Sprintf(graphHistoryFields, graphQLHistoryPage, after)
+		fmt.Fprintf(&body, graphRepoFields, head, history)
+		body.WriteString("\n  }\n")
+	}
+
+	query := fmt.Sprintf("query(%s) {\n%s}", strings.TrimSuffix(params.String(), ", "), body.String())
+	return query, vars
+}
+
+// ── Response decoding ────────────────────────────────────────────────────
+
+type gqlResponse struct {
+	Data   map[string]*gqlRepository `json:"data"`
+	Errors []gqlError                `json:"errors"`
+}
+
+type gqlError struct {
+	Type    string `json:"type"`
+	Path    []any  `json:"path"`
+	Message string `json:"message"`
+}
+
+type gqlRepository struct {
+	NameWithOwner    string    `json:"nameWithOwner"`
+	URL              string    `json:"url"`
+	Description      string    `json:"description"`
+	IsArchived       bool      `json:"isArchived"`
+	PushedAt         time.Time `json:"pushedAt"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	Head *struct {
+		Name   string `json:"name"`
+		Target struct {
+			History *gqlHistory `json:"history"`
+			// Target is set instead of History for annotated tags.
+			Target *struct {
+				History *gqlHistory `json:"history"`
+			} `json:"target"`
+		} `json:"target"`
+	} `json:"head"`
+	Releases struct {
+		Nodes []struct {
+			TagName      string    `json:"tagName"`
+			Name         string    `json:"name"`
+			Description  string    `json:"description"`
+			IsDraft      bool      `json:"isDraft"`
+			IsPrerelease bool      `json:"isPrerelease"`
+			PublishedAt  time.Time `json:"publishedAt"`
+			URL          string    `json:"url"`
+			TagCommit    *struct {
+				OID string `json:"oid"`
+			} `json:"tagCommit"`
+		} `json:"nodes"`
+	} `json:"releases"`
+}
+
+type gqlHistory struct {
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	Nodes []struct {
+		OID     string `json:"oid"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name string    `json:"name"`
+			Date time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"nodes"`
+}
+
+// historyWalk accumulates commits across history pages until Base is found.
+type historyWalk struct {
+	commits []Commit // newest first
+	found   bool
+	cursor  string
+	more    bool // history goes on past the last page fetched
+}
+
+// add appends a page of history, stopping at base. It reports whether
+// another page should be requested.
+func (w *historyWalk) add(h *gqlHistory, base string) bool {
+	for _, n := range h.Nodes {
+		if base != "" && strings.HasPrefix(n.OID, base) {
+			w.found = true
+			return false
+		}
+		w.commits = append(w.commits, Commit{
+			SHA:     n.OID,
+			HTMLURL: n.URL,
+			Commit: CommitDetail{
+				Message: n.Message,
+				Author:  CommitAuthor{Name: n.Author.Name, Date: n.Author.Date},
+			},
+		})
+	}
+	w.cursor = h.PageInfo.EndCursor
+	w.more = h.PageInfo.HasNextPage
+	return w.more
+}
+
+// fetchBatch runs one aliased query for refs, follows history pages for
+// repositories whose base commit was not reached, and stores the results.
+func (c *GraphQLClient) fetchBatch(ctx context.Context, refs []RepoRef) error {
+	walks := make([]historyWalk, len(refs))
+	repos := make([]*gqlRepository, len(refs))
+	errs := make([]error, len(refs))
+
+	pending := make([]int, len(refs))
+	for i := range refs {
+		pending[i] = i
+	}
+
+	for page := 0; len(pending) > 0 && page < graphQLMaxHistoryPages; page++ {
+		batch := make([]RepoRef, len(pending))
+		cursors := make([]string, len(pending))
+		for j, i := range pending {
+			batch[j] = refs[i]
+			cursors[j] = walks[i].cursor
+		}
+
+		resp, err := c.query(ctx, batch, cursors)
+		if err != nil {
+			return err
+		}
+
+		var next []int
+		for j, i := range pending {
+			alias := fmt.Sprintf("r%d", j)
+			repo := resp.Data[alias]
+			if repo == nil {
+				errs[i] = aliasError(resp.Errors, alias, refs[i])
+				continue
+			}
+			if page == 0 {
+				repos[i] = repo
+			}
+			if repo.Head == nil {
+				errs[i] = fmt.Errorf("%w: %q in %s/%s", ErrBranchNotFound, refs[i].Head, refs[i].Owner, refs[i].Repo)
+				continue
+			}
+			h := repo.Head.Target.History
+			if h == nil && repo.Head.Target.Target != nil {
+				h = repo.Head.Target.Target.History
+			}
+			if h != nil && walks[i].add(h, refs[i].Base) && refs[i].Base != "" {
+				next = append(next, i)
+			}
+		}
+		pending = next
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, ref := range refs {
+		r := &graphRepo{ref: ref, err: errs[i]}
+		if errors.Is(r.err, ErrBranchNotFound) {
+			// Repository metadata and releases do not depend on the head.
+			r.info, _, r.releases = convertRepo(ref, repos[i], &walks[i])
+			r.err, r.headErr = nil, errs[i]
+		}
+		if r.err == nil && r.headErr == nil {
+			r.info, r.compare, r.releases = convertRepo(ref, repos[i], &walks[i])
+			switch {
+			case ref.Base == "" || walks[i].found:
+			case walks[i].more:
+				// The base is further back than the pages walked: keep
+				// what was fetched and leave the total unknown.
+				r.compare.Truncated = true
+			default:
+				r.err = fmt.Errorf("%w: base commit %s in the history of %s/%s",
+					ErrNotFound, ref.Base, ref.Owner, ref.Repo)
+			}
+		}
+		c.store(r)
+	}
+	return nil
+}
+
+// store records r, replacing an earlier fetch of the same range. The
+// caller holds c.mu.
+func (c *GraphQLClient) store(r *graphRepo) {
+	key := r.ref.key()
+	for i, old := range c.repos[key] {
+		if old.ref == r.ref {
+			c.repos[key][i] = r
+			return
+		}
+	}
+	c.repos[key] = append(c.repos[key], r)
+}
+
+func aliasError(errs []gqlError, alias string, ref RepoRef) error {
+	for _, e := range errs {
+		if len(e.Path) > 0 && e.Path[0] == alias {
+			if e.Type == "NOT_FOUND" {
+				return fmt.Errorf("%w: %s/%s", ErrNotFound, ref.Owner, ref.Repo)
+			}
+			return fmt.Errorf("graphql: %s", e.Message)
+		}
+	}
+	return fmt.Errorf("%w: %s/%s", ErrNotFound, ref.Owner, ref.Repo)
+}
+
+// convertRepo maps a GraphQL repository onto the REST-shaped types.
+func convertRepo(ref RepoRef, repo *gqlRepository, walk *historyWalk) (RepoInfo, *CompareResult, []Release) {
+	info := RepoInfo{
+		FullName:    repo.NameWithOwner,
+		HTMLURL:     repo.URL,
+		Description: repo.Description,
+		Archived:    repo.IsArchived,
+		PushedAt:    repo.PushedAt,
+	}
+	if repo.DefaultBranchRef != nil {
+		info.DefaultBranch = repo.DefaultBranchRef.Name
+	}
+
+	head := strings.TrimPrefix(ref.Head, "refs/tags/")
+	if head == "" {
+		head = info.DefaultBranch
+	}
+
+	// The REST compare endpoint lists commits oldest first. The walk
+	// stopped at base, so base is an ancestor of head and BehindBy is 0; a
+	// base the walk never reached is either truncated or fails the lookup
+	// (see fetchBatch).
+	commits := make([]Commit, len(walk.commits))
+	for i, c := range walk.commits {
+		commits[len(commits)-1-i] = c
+	}
+	compare := &CompareResult{
+		Status:       "ahead",
+		AheadBy:      len(commits),
+		TotalCommits: len(commits),
+		Commits:      commits,
+		HTMLURL:      fmt.Sprintf("%s/compare/%s...%s", repo.URL, ref.Base, head),
+	}
+	if len(commits) == 0 {
+		compare.Status = "identical"
+	}
+
+	releases := make([]Release, 0, len(repo.Releases.Nodes))
+	for _, n := range repo.Releases.Nodes {
+		rel := Release{
+			TagName:     n.TagName,
+			Name:        n.Name,
+			Body:        n.Description,
+			Draft:       n.IsDraft,
+			Prerelease:  n.IsPrerelease,
+			PublishedAt: n.PublishedAt,
+			HTMLURL:     n.URL,
+		}
+		if n.TagCommit != nil {
+			rel.TargetCommit = n.TagCommit.OID
+		}
+		releases = append(releases, rel)
+	}
+
+	return info, compare, releases
+}
+
+// query sends one aliased repository query.
+func (c *GraphQLClient) query(ctx context.Context, refs []RepoRef, cursors []string) (*gqlResponse, error) {
+	query, vars := buildQuery(refs, cursors)
+	var out gqlResponse
+	if err := c.post(ctx, query, vars, &out); err != nil {
+		return nil, err
+	}
+	if out.Data == nil && len(out.Errors) > 0 {
+		return nil, fmt.Errorf("graphql: %s", out.Errors[0].Message)
+	}
+	return &out, nil
+}
+
+// post sends one GraphQL request and decodes the response into out.
+func (c *GraphQLClient) post(ctx context.Context, query string, vars map[string]any, out any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return fmt.Errorf("encoding query: %w", err)
+	}
+
+	resp, body, err := c.retry.do(ctx, c.httpClient, &c.limit, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "nvimgotrack/1.0")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("GraphQL API requires a token — set GITHUB_TOKEN")
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return statusError(resp, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body[:min(200, len(body))]))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeGraphQL answers aliased repository queries from a fixed set of repos.
+// Repositories not in repos are reported as NOT_FOUND.
+func fakeGraphQL(t *testing.T, repos map[string]map[string]any) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+
+		data := map[string]any{}
+		var errs []map[string]any
+		for i := 0; ; i++ {
+			owner, ok := req.Variables["o"+strconv.Itoa(i)].(string)
+			if !ok {
+				break
+			}
+			name := req.Variables["n"+strconv.Itoa(i)].(string)
+			alias := "r" + strconv.Itoa(i)
+			if repo, ok := repos[owner+"/"+name]; ok {
+				data[alias] = repo
+			} else {
+				data[alias] = nil
+				errs = append(errs, map[string]any{"type": "NOT_FOUND", "path": []any{alias}, "message": "Could not resolve"})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data, "errors": errs})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func repoFixture(fullName string, oids ...string) map[string]any {
+	var nodes []map[string]any
+	for _, oid := range oids {
+		nodes = append(nodes, map[string]any{
+			"oid":     oid,
+			"message": "commit " + oid,
+			"url":     "https://github.com/" + fullName + "/commit/" + oid,
+			"author":  map[string]any{"name": "dev", "date": "2025-01-01T00:00:00Z"},
+		})
+	}
+	return map[string]any{
+		"nameWithOwner":    fullName,
+		"url":              "https://github.com/" + fullName,
+		"isArchived":       false,
+		"defaultBranchRef": map[string]any{"name": "main"},
+		"head": map[string]any{
+			"name": "main",
+			"target": map[string]any{
+				"history": map[string]any{
+					"pageInfo": map[string]any{"hasNextPage": false},
+					"nodes":    nodes,
+				},
+			},
+		},
+		"releases": map[string]any{
+			"nodes": []map[string]any{
+				{"tagName": "v2.0.0", "name": "v2", "description": "BREAKING", "publishedAt": "2025-01-02T00:00:00Z"},
+			},
+		},
+	}
+}
+
+func TestGraphQLPrefetchBatchesRepos(t *testing.T) {
+	srv, calls := fakeGraphQL(t, map[string]map[string]any{
+		"folke/lazy.nvim":  repoFixture("folke/lazy.nvim", "ccc", "bbb", "aaa"),
+		"nvim-lua/plenary": repoFixture("nvim-lua/plenary", "fff"),
+		"moved/old-name":   repoFixture("moved/new-name", "111"),
+	})
+	c := NewGraphQLClient("test-token")
+	c.endpoint = srv.URL
+
+	refs := []RepoRef{
+		{Owner: "folke", Repo: "lazy.nvim", Base: "aaa", Head: "main"},
+		{Owner: "nvim-lua", Repo: "plenary", Base: "fff", Head: "main"},
+		{Owner: "moved", Repo: "old-name", Base: "111", Head: "main"},
+		{Owner: "ghost", Repo: "gone.nvim", Base: "000", Head: "main"},
+	}
+	if err := c.Prefetch(t.Context(), refs); err != nil {
+		t.Fatalf("Prefetch failed: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 request for 4 repos, got %d", n)
+	}
+
+	cmp, err := c.CompareCommits(t.Context(), "folke", "lazy.nvim", "aaa", "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.TotalCommits != 2 || cmp.Status != "ahead" || cmp.BehindBy != 0 {
+		t.Errorf("compare: got %d commits, %s, behind by %d; want 2, ahead, 0", cmp.TotalCommits, cmp.Status, cmp.BehindBy)
+	}
+	if cmp.Commits[0].SHA != "bbb" || cmp.Commits[1].SHA != "ccc" {
+		t.Errorf("commits should be oldest first, got %s, %s", cmp.Commits[0].SHA, cmp.Commits[1].SHA)
+	}
+
+	cmp, err = c.CompareCommits(t.Context(), "nvim-lua", "plenary", "fff", "main")
+	if err != nil || cmp.Status != "identical" {
+		t.Errorf("plenary: got %+v, %v; want identical", cmp, err)
+	}
+
+	releases, err := c.GetReleases(t.Context(), "folke", "lazy.nvim")
+	if err != nil || len(releases) != 1 || releases[0].TagName != "v2.0.0" {
+		t.Errorf("GetReleases: got %+v, %v", releases, err)
+	}
+
+	info, err := c.GetRepoInfo(t.Context(), "moved", "old-name")
+	if err != nil || info.FullName != "moved/new-name" {
+		t.Errorf("GetRepoInfo: got %+v, %v; want renamed full name", info, err)
+	}
+
+	if _, err := c.CompareCommits(t.Context(), "ghost", "gone.nvim", "000", "main"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected lookups to be served from the prefetch, got %d requests", n)
+	}
+}
+
+func TestGraphQLFetchesOnMiss(t *testing.T) {
+	srv, calls := fakeGraphQL(t, map[string]map[string]any{
+		"folke/lazy.nvim": repoFixture("folke/lazy.nvim", "ccc", "aaa"),
+	})
+	c := NewGraphQLClient("test-token")
+	c.endpoint = srv.URL
+
+	cmp, err := c.CompareCommits(t.Context(), "folke", "lazy.nvim", "aaa", "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.TotalCommits != 1 {
+		t.Errorf("TotalCommits: got %d, want 1", cmp.TotalCommits)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+}
+
+func TestGraphQLMissingBase(t *testing.T) {
+	srv, _ := fakeGraphQL(t, map[string]map[string]any{
+		"folke/lazy.nvim": repoFixture("folke/lazy.nvim", "ccc", "bbb"),
+	})
+	c := NewGraphQLClient("test-token")
+	c.endpoint = srv.URL
+
+	if _, err := c.CompareCommits(t.Context(), "folke", "lazy.nvim", "aaa", "main"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound when the base commit is not in history, got %v", err)
+	}
+}
+
+func TestGraphQLBaseBeyondWalk(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		repo := repoFixture("folke/lazy.nvim", "p"+strconv.Itoa(calls)+"a", "p"+strconv.Itoa(calls)+"b")
+		history := repo["head"].(map[string]any)["target"].(map[string]any)["history"].(map[string]any)
+		history["pageInfo"] = map[string]any{"hasNextPage": true, "endCursor": "c" + strconv.Itoa(calls)}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"r0": repo}})
+	}))
+	defer srv.Close()
+	c := NewGraphQLClientWithEndpoint(srv.URL, "test-token")
+
+	cmp, err := c.CompareCommits(t.Context(), "folke", "lazy.nvim", "aaa", "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	want := 2 * graphQLMaxHistoryPages
+	if !cmp.Truncated || len(cmp.Commits) != want || cmp.TotalCommits != want {
+		t.Errorf("got truncated %v with %d of %d commits, want %d truncated", cmp.Truncated, len(cmp.Commits), cmp.TotalCommits, want)
+	}
+	if calls != graphQLMaxHistoryPages {
+		t.Errorf("made %d queries, want %d", calls, graphQLMaxHistoryPages)
+	}
+}
+
+func TestBuildQueryUsesVariables(t *testing.T) {
+	query, vars := buildQuery([]RepoRef{
+		{Owner: "a", Repo: "b", Head: "main"},
+		{Owner: "c", Repo: "d"},
+	}, []string{"cursor1", ""})
+
+	for _, want := range []string{"r0: repository(owner: $o0, name: $n0)", "ref(qualifiedName: $b0)", "after: $c0", "r1: repository", "head: defaultBranchRef"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if vars["b0"] != "refs/heads/main" || vars["c0"] != "cursor1" {
+		t.Errorf("unexpected variables: %v", vars)
+	}
+	if _, ok := vars["c1"]; ok {
+		t.Error("no cursor variable expected for the second repo")
+	}
+}
+
+func TestGraphQLCompareAnnotatedTag(t *testing.T) {
+	repo := repoFixture("folke/lazy.nvim", "ccc", "bbb", "aaa")
+	// An annotated tag points at a Tag object that wraps the commit.
+	head := repo["head"].(map[string]any)
+	head["target"] = map[string]any{"target": head["target"]}
+	srv, _ := fakeGraphQL(t, map[string]map[string]any{"folke/lazy.nvim": repo})
+	c := NewGraphQLClient("test-token")
+	c.endpoint = srv.URL
+
+	if _, err := c.GetReleases(t.Context(), "folke", "lazy.nvim"); err != nil {
+		t.Fatalf("GetReleases failed: %v", err)
+	}
+	cmp, err := c.CompareCommits(t.Context(), "folke", "lazy.nvim", "aaa", "v2.0.0")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.TotalCommits != 2 || !strings.HasSuffix(cmp.HTMLURL, "aaa...v2.0.0") {
+		t.Errorf("got %d commits, url %s", cmp.TotalCommits, cmp.HTMLURL)
+	}
+
+	_, vars := buildQuery([]RepoRef{{Owner: "folke", Repo: "lazy.nvim", Head: "refs/tags/v2.0.0"}}, nil)
+	if vars["b0"] != "refs/tags/v2.0.0" {
+		t.Errorf("tag ref: got %v", vars["b0"])
+	}
+}
+
+func TestGraphQLMissingBranch(t *testing.T) {
+	repo := repoFixture("owner/plugin", "c2", "c1")
+	repo["head"] = nil
+	srv, calls := fakeGraphQL(t, map[string]map[string]any{"owner/plugin": repo})
+	c := NewGraphQLClientWithEndpoint(srv.URL, "test-token")
+
+	if err := c.Prefetch(t.Context(), []RepoRef{{Owner: "owner", Repo: "plugin", Base: "c1", Head: "master"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CompareCommits(t.Context(), "owner", "plugin", "c1", "master"); !errors.Is(err, ErrBranchNotFound) {
+		t.Errorf("CompareCommits error = %v, want ErrBranchNotFound", err)
+	}
+	info, err := c.GetRepoInfo(t.Context(), "owner", "plugin")
+	if err != nil || info.DefaultBranch != "main" {
+		t.Errorf("GetRepoInfo = %+v, %v; want default branch main", info, err)
+	}
+	if ok, err := c.HasBranch(t.Context(), "owner", "plugin", "master"); ok || err != nil {
+		t.Errorf("HasBranch(master) = %v, %v; want false", ok, err)
+	}
+	// The prefetch, then the lookup of a tag by that name.
+	if n := calls.Load(); n != 2 {
+		t.Errorf("made %d queries, want 2", n)
+	}
+}
+
+func TestGraphQLPrefetchKeepsEachBase(t *testing.T) {
+	srv, calls := fakeGraphQL(t, map[string]map[string]any{
+		"folke/lazy.nvim": repoFixture("folke/lazy.nvim", "ccc", "bbb", "aaa"),
+	})
+	c := NewGraphQLClientWithEndpoint(srv.URL, "test-token")
+
+	refs := []RepoRef{
+		{Owner: "folke", Repo: "lazy.nvim", Base: "aaa", Head: "main"},
+		{Owner: "folke", Repo: "lazy.nvim", Base: "bbb", Head: "main"},
+	}
+	if err := c.Prefetch(t.Context(), refs); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		base string
+		want int
+	}{{"aaa", 2}, {"bbb", 1}} {
+		cmp, err := c.CompareCommits(t.Context(), "folke", "lazy.nvim", tt.base, "main")
+		if err != nil || cmp.TotalCommits != tt.want {
+			t.Errorf("CompareCommits(%s) = %+v, %v; want %d commits", tt.base, cmp, err, tt.want)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("made %d queries, want 1", n)
+	}
+}
+
+func TestGraphQLURLForAPI(t *testing.T) {
+	tests := []struct{ api, want string }{
+		{"https://api.github.com", "https://api.github.com/graphql"},
+		{"https://ghe.example.com/api/v3", "https://ghe.example.com/api/graphql"},
+		{"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/graphql"},
+		{"http://proxy.local/github", "http://proxy.local/github/graphql"},
+	}
+	for _, tt := range tests {
+		if got := GraphQLURLForAPI(tt.api); got != tt.want {
+			t.Errorf("GraphQLURLForAPI(%q) = %q, want %q", tt.api, got, tt.want)
+		}
+	}
+}
+
+func TestGraphQLCompareTagWithoutRelease(t *testing.T) {
+	var heads []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+		head, _ := req.Variables["b0"].(string)
+		heads = append(heads, head)
+		repo := repoFixture("owner/plugin", "c3", "c2", "c1")
+		if head == "refs/heads/v1.0" {
+			repo["head"] = nil
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"r0": repo}})
+	}))
+	defer srv.Close()
+	c := NewGraphQLClientWithEndpoint(srv.URL, "test-token")
+
+	cmp, err := c.CompareCommits(t.Context(), "owner", "plugin", "c1", "v1.0")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.TotalCommits != 2 {
+		t.Errorf("TotalCommits = %d, want 2", cmp.TotalCommits)
+	}
+	if want := []string{"refs/heads/v1.0", "refs/tags/v1.0"}; strings.Join(heads, ",") != strings.Join(want, ",") {
+		t.Errorf("queried heads %q, want %q", heads, want)
+	}
+}
+
+func TestGraphQLListTags(t *testing.T) {
+	var cursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+			return
+		}
+		if !strings.Contains(req.Query, `refPrefix: "refs/tags/"`) {
+			t.Errorf("query does not list tags: %s", req.Query)
+		}
+		after, _ := req.Variables["after"].(string)
+		cursors = append(cursors, after)
+		refs := map[string]any{
+			"pageInfo": map[string]any{"hasNextPage": true, "endCursor": "page2"},
+			"nodes":    []map[string]any{{"name": "v1.1.0"}, {"name": "v1.0.0"}},
+		}
+		if after == "page2" {
+			refs = map[string]any{
+				"pageInfo": map[string]any{"hasNextPage": false},
+				"nodes":    []map[string]any{{"name": "v0.9.0"}},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": map[string]any{"refs": refs}}})
+	}))
+	defer srv.Close()
+	c := NewGraphQLClientWithEndpoint(srv.URL, "test-token")
+
+	tags, err := c.ListTags(t.Context(), "owner", "plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(tags, ","), "v1.1.0,v1.0.0,v0.9.0"; got != want {
+		t.Errorf("tags = %s, want %s", got, want)
+	}
+	if got := strings.Join(cursors, ","); got != ",page2" {
+		t.Errorf("cursors = %q, want first page then page2", got)
+	}
+}
+
+func TestGraphQLListTagsMissingRepo(t *testing.T) {
+	srv, _ := fakeGraphQL(t, nil)
+	c := NewGraphQLClientWithEndpoint(srv.URL, "test-token")
+	if _, err := c.ListTags(t.Context(), "owner", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
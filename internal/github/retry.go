@@ -0,0 +1,274 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrForbidden is wrapped by errors for 403 responses that are not rate
+// limits, e.g. a private repository the token cannot read.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrRateLimited is wrapped by errors for requests still rate limited
+// after waiting, or whose reset is too far away to wait for.
+var ErrRateLimited = errors.New("rate limited")
+
+// Pause is reported when requests stop until a rate limit resets.
+type Pause struct {
+	Until  time.Time
+	Reason string // "rate limit" or "secondary rate limit"
+}
+
+const (
+	// retryAttempts bounds the tries for transient failures: 5xx
+	// responses, timeouts and dropped connections.
+	retryAttempts = 4
+	// rateLimitWaits bounds how often one request waits out a rate limit.
+	rateLimitWaits = 3
+	// secondaryLimitWait is GitHub's advice for secondary rate limit
+	// responses that carry no Retry-After.
+	secondaryLimitWait = time.Minute
+	// maxPause is the longest reset a request waits for before failing.
+	maxPause = time.Hour
+)
+
+// retryPolicy sends requests with exponential backoff and jitter.
+type retryPolicy struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+var defaultRetry = retryPolicy{baseDelay: time.Second, maxDelay: 30 * time.Second}
+
+// do sends the request built by newReq, retrying transient failures and
+// waiting out rate limits through limit, so every caller sharing it pauses
+// together. It returns the last response with its body already read. A
+// rate limit that resets after ctx's deadline is not waited for.
+func (p retryPolicy) do(ctx context.Context, client *http.Client, limit *rateLimit, newReq func() (*http.Request, error)) (*http.Response, []byte, error) {
+	var attempts, waits int
+	for {
+		req, err := newReq()
+		if err != nil {
+			return nil, nil, fmt.Errorf("building request: %w", err)
+		}
+
+		if err := limit.acquire(ctx); err != nil {
+			return nil, nil, err
+		}
+		attempts++
+		resp, err := client.Do(req)
+		if err != nil {
+			if attempts < retryAttempts && isTransient(err) && sleep(ctx, p.backoff(attempts)) == nil {
+				continue
+			}
+			return nil, nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		limit.update(resp.Header)
+		if err != nil {
+			if attempts < retryAttempts && sleep(ctx, p.backoff(attempts)) == nil {
+				continue
+			}
+			return nil, nil, fmt.Errorf("reading response: %w", err)
+		}
+
+		now := time.Now()
+		if until, reason, ok := rateLimitedUntil(resp, body, now); ok {
+			deadline, hasDeadline := ctx.Deadline()
+			if waits < rateLimitWaits && until.Sub(now) <= maxPause && (!hasDeadline || until.Before(deadline)) {
+				waits++
+				limit.block(until, reason)
+				continue
+			}
+			return resp, body, nil
+		}
+		if resp.StatusCode >= 500 && attempts < retryAttempts {
+			delay := p.backoff(attempts)
+			if until, ok := retryAfter(resp.Header, now); ok {
+				delay = until.Sub(now)
+			}
+			if sleep(ctx, delay) == nil {
+				continue
+			}
+		}
+		return resp, body, nil
+	}
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff is the delay before retry n (from 1): exponential, capped, with
+// half of it randomized so concurrent workers do not retry in lockstep.
+func (p retryPolicy) backoff(n int) time.Duration {
+	d := min(p.baseDelay<<(n-1), p.maxDelay)
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// isTransient reports whether a transport error is worth retrying.
+func isTransient(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// rateLimitedUntil reports whether resp is a rate limit response and
+// when to retry. GitHub answers both primary and secondary limits with 403
+// or 429; a 403 that is neither means access was denied.
+func rateLimitedUntil(resp *http.Response, body []byte, now time.Time) (time.Time, string, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, "", false
+	}
+	secondary := bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit")) ||
+		bytes.Contains(bytes.ToLower(body), []byte("abuse"))
+	if until, ok := retryAfter(resp.Header, now); ok {
+		return until, "secondary rate limit", true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" && !secondary {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err == nil {
+			return time.Unix(reset, 0).Add(time.Second), "rate limit", true
+		}
+	}
+	if secondary || resp.StatusCode == http.StatusTooManyRequests {
+		return now.Add(secondaryLimitWait), "secondary rate limit", true
+	}
+	return time.Time{}, "", false
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Time, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// statusError describes a failed 403 or 429 response.
+func statusError(resp *http.Response, body []byte) error {
+	if until, reason, ok := rateLimitedUntil(resp, body, time.Now()); ok {
+		return fmt.Errorf("%w (%s) until %s — set GITHUB_TOKEN env var for higher limits",
+			ErrRateLimited, reason, until.Local().Format(time.TimeOnly))
+	}
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &msg) != nil || msg.Message == "" {
+		msg.Message = "the token cannot access this repository"
+	}
+	return fmt.Errorf("%w: %s", ErrForbidden, msg.Message)
+}
+
+// rateLimit tracks the quota reported by GitHub's X-RateLimit-* headers,
+// and any secondary limit block, so concurrent callers wait for the reset
+// instead of getting a 403.
+type rateLimit struct {
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+	blocked   time.Time // secondary limit: no requests before this
+	announced time.Time // last Until passed to onPause
+	onPause   func(Pause)
+}
+
+// acquire blocks until the quota allows another request and reserves it,
+// or ctx is done. The first caller to wait for a given reset reports it to
+// onPause.
+func (l *rateLimit) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		exhausted := l.known && l.remaining <= 0
+		until, reason := l.blocked, "secondary rate limit"
+		if exhausted && l.reset.After(until) {
+			until, reason = l.reset, "rate limit"
+		}
+		if !until.After(time.Now()) {
+			switch {
+			case exhausted:
+				// The window has reset; let requests through until the
+				// next response tells us the new quota.
+				l.known = false
+			case l.known:
+				l.remaining--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		announce := l.onPause != nil && !until.Equal(l.announced)
+		l.announced = until
+		l.mu.Unlock()
+
+		if announce {
+			l.onPause(Pause{Until: until, Reason: reason})
+		}
+		if err := sleep(ctx, time.Until(until)); err != nil {
+			return err
+		}
+	}
+}
+
+// update records the quota from a response.
+func (l *rateLimit) update(h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.known = true
+	l.remaining = remaining
+	l.reset = time.Unix(reset, 0)
+}
+
+// block stops requests until until after a rate limit response.
+func (l *rateLimit) block(until time.Time, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if reason == "rate limit" {
+		l.known, l.remaining = true, 0
+		if until.After(l.reset) {
+			l.reset = until
+		}
+		return
+	}
+	if until.After(l.blocked) {
+		l.blocked = until
+	}
+}
@@ -41,12 +41,27 @@ type CompareResult struct {
 	TotalCommits int      `json:"total_commits"`
 	Commits      []Commit `json:"commits"`
 	HTMLURL      string   `json:"html_url"`
+	// BaseCommit is the compared base; not every source fills it in.
+	BaseCommit Commit `json:"base_commit"`
+	// Truncated is set when Commits stops short of base and the total is
+	// unknown; TotalCommits then counts only the listed commits.
+	Truncated bool `json:"-"`
+}
+
+// FileContent is a file read from a repository at some ref.
+type FileContent struct {
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+	HTMLURL  string `json:"html_url"`
 }
 
 // RepoInfo holds basic repository metadata.
 type RepoInfo struct {
-	FullName      string `json:"full_name"`
-	DefaultBranch string `json:"default_branch"`
-	HTMLURL       string `json:"html_url"`
-	Description   string `json:"description"`
+	FullName      string    `json:"full_name"`
+	DefaultBranch string    `json:"default_branch"`
+	HTMLURL       string    `json:"html_url"`
+	Description   string    `json:"description"`
+	Archived      bool      `json:"archived"`
+	PushedAt      time.Time `json:"pushed_at"`
 }
@@ -0,0 +1,466 @@
+// Package gitlocal answers compare and release queries from the plugin
+// checkouts lazy.nvim keeps on disk, without talking to any forge API.
+package gitlocal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+
+	// logFormat is the `git log` format parseLog reads.
+	logFormat = "%H" + fieldSep + "%an" + fieldSep + "%aI" + fieldSep + "%B" + recordSep
+)
+
+// Backend reads commit ranges and tags from local clones. It implements the
+// same CompareCommits/GetReleases methods as the GitHub clients.
+type Backend struct {
+	dirs  map[string]string // lowercased "owner/repo" → checkout dir
+	fetch bool
+
+	mu      sync.Mutex
+	fetches map[string]*repoFetch // by checkout dir
+}
+
+// repoFetch is the `git fetch` of one checkout. Its own lock lets checkouts
+// fetch in parallel while each is fetched only once.
+type repoFetch struct {
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+// revRe matches the commit SHAs, branch and tag names passed to git. It
+// keeps a lockfile or spec value from being read as an option or a range.
+var revRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_./+-]*$`)
+
+// checkRev rejects a revision git could misread.
+func checkRev(rev string) error {
+	if !revRe.MatchString(rev) || strings.Contains(rev, "..") {
+		return fmt.Errorf("invalid revision %q", rev)
+	}
+	return nil
+}
+
+// DefaultRoot returns the directory lazy.nvim clones plugins into for the
+// active NVIM_APPNAME profile.
+func DefaultRoot() string {
+	return ProfileRoot(parser.AppName())
+}
+
+// ProfileRoot returns the lazy.nvim clone directory of a profile:
+// $XDG_DATA_HOME/<appname>/lazy, falling back to ~/.local/share/<appname>/lazy.
+func ProfileRoot(appName string) string {
+	return filepath.Join(parser.DataDir(appName), "lazy")
+}
+
+// New returns a Backend for plugins checked out under root/<plugin name>.
+// An empty root means each plugin's own clone directory: that of its first
+// profile when it has any, DefaultRoot otherwise. If fetch is true, each
+// checkout runs `git fetch` once before its first query so the remote
+// branch and tags are current.
+func New(root string, plugins []parser.Plugin, fetch bool) *Backend {
+	b := &Backend{
+		dirs:    make(map[string]string, len(plugins)),
+		fetch:   fetch,
+		fetches: make(map[string]*repoFetch),
+	}
+	for _, p := range plugins {
+		b.dirs[repoKey(p.Owner, p.Repo)] = checkoutDir(root, p)
+	}
+	return b
+}
+
+// checkoutDir is where lazy.nvim cloned p, under root when it is set.
+func checkoutDir(root string, p parser.Plugin) string {
+	switch {
+	case root != "":
+	case len(p.Profiles) > 0:
+		root = ProfileRoot(p.Profiles[0])
+	default:
+		root = DefaultRoot()
+	}
+	return filepath.Join(root, p.Name)
+}
+
+func repoKey(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+// CompareCommits lists the commits between base and the remote-tracking
+// branch origin/<head> in the plugin's checkout, oldest first.
+func (b *Backend) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CompareResult, error) {
+	if err := checkRev(base); err != nil {
+		return nil, err
+	}
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	headRef, err := resolveHead(ctx, dir, head)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: commit %s in %s", github.ErrNotFound, base, dir)
+	}
+
+	out, err := git(ctx, dir, "log", "--reverse", "--format="+logFormat, base+".."+headRef)
+	if err != nil {
+		return nil, err
+	}
+	commits := parseLog(out)
+
+	behind, err := git(ctx, dir, "rev-list", "--count", headRef+".."+base)
+	if err != nil {
+		return nil, err
+	}
+	behindBy, _ := strconv.Atoi(strings.TrimSpace(behind))
+
+	result := &github.CompareResult{
+		AheadBy:      len(commits),
+		BehindBy:     behindBy,
+		TotalCommits: len(commits),
+		Commits:      commits,
+		HTMLURL:      compareURL(ctx, dir, base, head),
+	}
+	if c, err := commitAt(ctx, dir, base); err == nil {
+		result.BaseCommit = *c
+	}
+	switch {
+	case result.AheadBy > 0 && behindBy > 0:
+		result.Status = "diverged"
+	case result.AheadBy > 0:
+		result.Status = "ahead"
+	case behindBy > 0:
+		result.Status = "behind"
+	default:
+		result.Status = "identical"
+	}
+	return result, nil
+}
+
+// GetReleases returns the checkout's tags, newest first, shaped as
+// releases. Annotated tags carry their message as the release body.
+func (b *Backend) GetReleases(ctx context.Context, owner, repo string) ([]github.Release, error) {
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	format := strings.Join([]string{
+		"%(refname:short)", "%(objecttype)", "%(creatordate:iso-strict)",
+		"%(objectname)", "%(*objectname)", "%(contents)",
+	}, fieldSep) + recordSep
+	out, err := git(ctx, dir, "for-each-ref", "--sort=-creatordate", "--format="+format, "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []github.Release
+	for _, rec := range strings.Split(out, recordSep) {
+		f := strings.SplitN(strings.TrimLeft(rec, "\n"), fieldSep, 6)
+		if len(f) < 6 {
+			continue
+		}
+		rel := github.Release{TagName: f[0], Name: f[0], TargetCommit: f[3]}
+		rel.PublishedAt, _ = time.Parse(time.RFC3339, f[2])
+		if f[1] == "tag" {
+			// Annotated tag: the peeled object is the commit.
+			rel.TargetCommit = f[4]
+			rel.Body = strings.TrimSpace(f[5])
+		}
+		releases = append(releases, rel)
+	}
+	return releases, nil
+}
+
+// ListTags returns the names of the checkout's tags, newest first.
+func (b *Backend) ListTags(ctx context.Context, owner, repo string) ([]string, error) {
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, dir, "for-each-ref", "--sort=-creatordate", "--format=%(refname:short)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// GetRepoInfo describes the checkout's origin remote. PushedAt is the date
+// of the newest commit on the remote default branch.
+func (b *Backend) GetRepoInfo(ctx context.Context, owner, repo string) (*github.RepoInfo, error) {
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &github.RepoInfo{FullName: owner + "/" + repo}
+	if out, err := git(ctx, dir, "remote", "get-url", "origin"); err == nil {
+		url := strings.TrimSuffix(strings.TrimSpace(out), ".git")
+		if strings.HasPrefix(url, "https://") {
+			info.HTMLURL = url
+		}
+	}
+	if out, err := git(ctx, dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		info.DefaultBranch = strings.TrimPrefix(strings.TrimSpace(out), "origin/")
+	}
+	if out, err := git(ctx, dir, "log", "-1", "--format=%cI", "origin/HEAD"); err == nil {
+		info.PushedAt, _ = time.Parse(time.RFC3339, strings.TrimSpace(out))
+	}
+	return info, nil
+}
+
+// HasBranch reports whether the origin remote has branch, as of the last
+// fetch.
+func (b *Backend) HasBranch(ctx context.Context, owner, repo, branch string) (bool, error) {
+	if err := checkRev(branch); err != nil {
+		return false, err
+	}
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return false, err
+	}
+	_, err = git(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch)
+	return err == nil, nil
+}
+
+// GetCommit returns the commit sha from the checkout.
+func (b *Backend) GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, error) {
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return commitAt(ctx, dir, sha)
+}
+
+// GetFile reads the file at path as of the remote branch ref. lazy.nvim
+// clones with --filter=blob:none, so the file may not be in the clone;
+// reading it would then download it, and unless the backend fetches
+// anyway it is reported as not found instead.
+func (b *Backend) GetFile(ctx context.Context, owner, repo, path, ref string) (*github.FileContent, error) {
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	headRef, err := resolveHead(ctx, dir, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !b.fetch && !hasObject(ctx, dir, headRef+":"+path) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %s at %s in %s", github.ErrNotFound, path, headRef, dir)
+	}
+	out, err := git(ctx, dir, "show", headRef+":"+path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s at %s in %s", github.ErrNotFound, path, headRef, dir)
+	}
+	return &github.FileContent{Path: path, Content: out}, nil
+}
+
+// CommitFiles lists the paths a commit changed.
+func (b *Backend) CommitFiles(ctx context.Context, owner, repo, sha string) ([]string, error) {
+	if err := checkRev(sha); err != nil {
+		return nil, err
+	}
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, dir, "diff-tree", "--root", "--no-commit-id", "--name-only", "-r", sha)
+	if err != nil {
+		return nil, err
+	}
+	if out = strings.TrimSpace(out); out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// CommitBefore returns the newest commit on the remote branch made no
+// later than until.
+func (b *Backend) CommitBefore(ctx context.Context, owner, repo, branch string, until time.Time) (*github.Commit, error) {
+	dir, err := b.checkout(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	headRef, err := resolveHead(ctx, dir, branch)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, dir, "log", "-1", "--before="+until.Format(time.RFC3339), "--format="+logFormat, headRef)
+	if err != nil {
+		return nil, err
+	}
+	commits := parseLog(out)
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: no commit on %s before %s", github.ErrNotFound, branch, until.Format(time.DateOnly))
+	}
+	return &commits[0], nil
+}
+
+// commitAt reads a single commit from the clone at dir.
+func commitAt(ctx context.Context, dir, sha string) (*github.Commit, error) {
+	if err := checkRev(sha); err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", sha+"^{commit}"); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: commit %s in %s", github.ErrNotFound, sha, dir)
+	}
+	out, err := git(ctx, dir, "log", "-1", "--format="+logFormat, sha)
+	if err != nil {
+		return nil, err
+	}
+	commits := parseLog(out)
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: commit %s in %s", github.ErrNotFound, sha, dir)
+	}
+	return &commits[0], nil
+}
+
+// checkout returns the clone for owner/repo, fetching it first if enabled.
+func (b *Backend) checkout(ctx context.Context, owner, repo string) (string, error) {
+	dir, ok := b.dirs[repoKey(owner, repo)]
+	if !ok {
+		return "", fmt.Errorf("no local checkout for %s/%s", owner, repo)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return "", fmt.Errorf("not a git checkout: %s", dir)
+	}
+	if !b.fetch {
+		return dir, nil
+	}
+
+	b.mu.Lock()
+	f, ok := b.fetches[dir]
+	if !ok {
+		f = &repoFetch{}
+		b.fetches[dir] = f
+	}
+	b.mu.Unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.err
+	if !f.done {
+		_, err = git(ctx, dir, "fetch", "--quiet", "--tags", "origin")
+		if ctx.Err() == nil {
+			// A canceled fetch is retried by the next run.
+			f.done, f.err = true, err
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("git fetch in %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// resolveHead prefers the remote-tracking branch, since the local branch is
+// what lazy.nvim checked out at the locked commit.
+func resolveHead(ctx context.Context, dir, head string) (string, error) {
+	if head != "" {
+		if err := checkRev(head); err != nil {
+			return "", err
+		}
+	}
+	candidates := []string{"origin/HEAD"}
+	if head != "" {
+		candidates = []string{"origin/" + head, head}
+	}
+	for _, ref := range candidates {
+		if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("branch %s not found in %s", candidates[0], dir)
+}
+
+func parseLog(out string) []github.Commit {
+	var commits []github.Commit
+	for _, rec := range strings.Split(out, recordSep) {
+		f := strings.SplitN(strings.TrimLeft(rec, "\n"), fieldSep, 4)
+		if len(f) < 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, f[2])
+		commits = append(commits, github.Commit{
+			SHA: f[0],
+			Commit: github.CommitDetail{
+				Message: strings.TrimSpace(f[3]),
+				Author:  github.CommitAuthor{Name: f[1], Date: date},
+			},
+		})
+	}
+	return commits
+}
+
+// compareURL builds a GitHub compare link when origin points at github.com.
+func compareURL(ctx context.Context, dir, base, head string) string {
+	out, err := git(ctx, dir, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	url := strings.TrimSuffix(strings.TrimSpace(out), ".git")
+	if !strings.HasPrefix(url, "https://github.com/") {
+		return ""
+	}
+	return fmt.Sprintf("%s/compare/%s...%s", url, base, head)
+}
+
+// hasObject reports whether rev names an object stored in the clone.
+// Unlike cat-file or show, rev-list with --missing does not fetch a
+// missing object from the promisor remote of a partial clone.
+func hasObject(ctx context.Context, dir, rev string) bool {
+	oid, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", rev)
+	if err != nil {
+		return false
+	}
+	_, err = git(ctx, dir, "rev-list", "--objects", "--missing=print", strings.TrimSpace(oid))
+	return err == nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s: %w", args[0], ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,199 @@
+package gitlocal
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/github"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// run executes git in dir, failing the test on error.
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=dev", "GIT_AUTHOR_EMAIL=dev@example.com",
+		"GIT_COMMITTER_NAME=dev", "GIT_COMMITTER_EMAIL=dev@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setup creates an upstream repo and a lazy-style checkout of it pinned at
+// the first commit, then adds more upstream commits and an annotated tag.
+func setup(t *testing.T) (root, base string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	upstream := t.TempDir()
+	run(t, upstream, "init", "--quiet", "--initial-branch=main")
+	run(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "init")
+	run(t, upstream, "tag", "v1.0.0")
+	base = run(t, upstream, "rev-parse", "HEAD")
+
+	root = t.TempDir()
+	run(t, root, "clone", "--quiet", upstream, filepath.Join(root, "demo.nvim"))
+
+	run(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "feat: add picker")
+	run(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "feat!: drop setup()\n\nBREAKING CHANGE: use opts")
+	run(t, upstream, "tag", "-a", "v2.0.0", "-m", "v2.0.0\n\nBreaking release")
+	return root, base
+}
+
+func TestCompareWithFetch(t *testing.T) {
+	root, base := setup(t)
+	plugins := []parser.Plugin{{Name: "demo.nvim", Owner: "someone", Repo: "demo.nvim", Branch: "main", Commit: base}}
+
+	// Without fetching, the checkout has not seen the new commits.
+	cmp, err := New(root, plugins, false).CompareCommits(t.Context(), "someone", "demo.nvim", base, "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.Status != "identical" || cmp.TotalCommits != 0 {
+		t.Errorf("before fetch: got status %q with %d commits", cmp.Status, cmp.TotalCommits)
+	}
+
+	b := New(root, plugins, true)
+	cmp, err = b.CompareCommits(t.Context(), "someone", "demo.nvim", base, "main")
+	if err != nil {
+		t.Fatalf("CompareCommits failed: %v", err)
+	}
+	if cmp.Status != "ahead" || cmp.TotalCommits != 2 {
+		t.Fatalf("after fetch: got status %q with %d commits", cmp.Status, cmp.TotalCommits)
+	}
+	if cmp.Commits[0].Commit.Message != "feat: add picker" {
+		t.Errorf("commits should be oldest first, got %q", cmp.Commits[0].Commit.Message)
+	}
+	if !strings.Contains(cmp.Commits[1].Commit.Message, "BREAKING CHANGE") {
+		t.Errorf("expected full message body, got %q", cmp.Commits[1].Commit.Message)
+	}
+
+	releases, err := b.GetReleases(t.Context(), "someone", "demo.nvim")
+	if err != nil {
+		t.Fatalf("GetReleases failed: %v", err)
+	}
+	tags := map[string]string{}
+	for _, r := range releases {
+		tags[r.TagName] = r.Body
+	}
+	if _, ok := tags["v1.0.0"]; !ok {
+		t.Errorf("missing lightweight tag v1.0.0 in %v", tags)
+	}
+	if !strings.Contains(tags["v2.0.0"], "Breaking release") {
+		t.Errorf("annotated tag body: got %q", tags["v2.0.0"])
+	}
+}
+
+func TestGetRepoInfo(t *testing.T) {
+	root, base := setup(t)
+	plugins := []parser.Plugin{{Name: "demo.nvim", Owner: "someone", Repo: "demo.nvim", Branch: "main", Commit: base}}
+
+	info, err := New(root, plugins, false).GetRepoInfo(t.Context(), "someone", "demo.nvim")
+	if err != nil {
+		t.Fatalf("GetRepoInfo failed: %v", err)
+	}
+	if info.DefaultBranch != "main" {
+		t.Errorf("default branch: got %q, want main", info.DefaultBranch)
+	}
+	if info.PushedAt.IsZero() {
+		t.Error("expected PushedAt from the remote head commit")
+	}
+}
+
+func TestUnknownPlugin(t *testing.T) {
+	b := New(t.TempDir(), nil, false)
+	if _, err := b.CompareCommits(t.Context(), "a", "b", "abc", "main"); err == nil {
+		t.Error("expected error for a plugin without a checkout")
+	}
+}
+
+func TestCommitLookups(t *testing.T) {
+	root, base := setup(t)
+	plugins := []parser.Plugin{{Name: "demo.nvim", Owner: "someone", Repo: "demo.nvim", Branch: "main", Commit: base}}
+	b := New(root, plugins, true)
+
+	c, err := b.GetCommit(t.Context(), "someone", "demo.nvim", base)
+	if err != nil || c.SHA != base || c.Commit.Message != "init" {
+		t.Fatalf("GetCommit = %+v, %v", c, err)
+	}
+	if files, err := b.CommitFiles(t.Context(), "someone", "demo.nvim", base); err != nil || len(files) != 0 {
+		t.Errorf("CommitFiles(empty commit) = %q, %v", files, err)
+	}
+	if _, err := b.GetCommit(t.Context(), "someone", "demo.nvim", strings.Repeat("0", 40)); !errors.Is(err, github.ErrNotFound) {
+		t.Errorf("GetCommit(unknown) error = %v, want ErrNotFound", err)
+	}
+
+	c, err = b.CommitBefore(t.Context(), "someone", "demo.nvim", "main", time.Now().Add(time.Hour))
+	if err != nil || !strings.HasPrefix(c.Commit.Message, "feat!: drop setup()") {
+		t.Errorf("CommitBefore(now) = %+v, %v; want the branch head", c, err)
+	}
+	if _, err := b.CommitBefore(t.Context(), "someone", "demo.nvim", "main", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, github.ErrNotFound) {
+		t.Errorf("CommitBefore(2000) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRejectsOptionLikeRevisions(t *testing.T) {
+	root, base := setup(t)
+	plugins := []parser.Plugin{{Name: "demo.nvim", Owner: "someone", Repo: "demo.nvim", Branch: "main", Commit: base}}
+	b := New(root, plugins, false)
+
+	for _, rev := range []string{"--output=/tmp/x", "-p", base + "..main", ""} {
+		if _, err := b.CompareCommits(t.Context(), "someone", "demo.nvim", rev, "main"); err == nil || !strings.Contains(err.Error(), "invalid revision") {
+			t.Errorf("CompareCommits(base %q) error = %v, want invalid revision", rev, err)
+		}
+		if _, err := b.GetCommit(t.Context(), "someone", "demo.nvim", rev); err == nil {
+			t.Errorf("GetCommit(%q) succeeded", rev)
+		}
+	}
+	if _, err := b.CompareCommits(t.Context(), "someone", "demo.nvim", base, "--all"); err == nil || !strings.Contains(err.Error(), "invalid revision") {
+		t.Errorf("CompareCommits(head --all) error = %v, want invalid revision", err)
+	}
+}
+
+func TestGetFileInPartialClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	upstream := t.TempDir()
+	run(t, upstream, "init", "--quiet", "--initial-branch=main")
+	run(t, upstream, "config", "uploadpack.allowFilter", "true")
+	run(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "init")
+
+	// Like lazy.nvim: a blobless clone, then a fetch that brings in a new
+	// commit without its blobs.
+	root := t.TempDir()
+	dir := filepath.Join(root, "demo.nvim")
+	run(t, root, "clone", "--quiet", "--filter=blob:none", "file://"+upstream, dir)
+	run(t, upstream, "commit", "--quiet", "--allow-empty", "-m", "docs")
+	if err := os.WriteFile(filepath.Join(upstream, "CHANGELOG.md"), []byte("## 1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, upstream, "add", "CHANGELOG.md")
+	run(t, upstream, "commit", "--quiet", "-m", "docs: changelog")
+	run(t, dir, "fetch", "--quiet", "origin")
+
+	plugins := []parser.Plugin{{Name: "demo.nvim", Owner: "someone", Repo: "demo.nvim", Branch: "main"}}
+	if _, err := New(root, plugins, false).GetFile(t.Context(), "someone", "demo.nvim", "CHANGELOG.md", "main"); !errors.Is(err, github.ErrNotFound) {
+		t.Fatalf("GetFile without -fetch: error = %v, want ErrNotFound", err)
+	}
+	if hasObject(t.Context(), dir, "origin/main:CHANGELOG.md") {
+		t.Fatal("GetFile without -fetch downloaded the blob")
+	}
+
+	f, err := New(root, plugins, true).GetFile(t.Context(), "someone", "demo.nvim", "CHANGELOG.md", "main")
+	if err != nil || f.Content != "## 1.0.0\n" {
+		t.Errorf("GetFile with -fetch = %+v, %v", f, err)
+	}
+}
@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format identifies the plugin manager that wrote a lockfile.
+type Format string
+
+const (
+	FormatLazy           Format = "lazy"            // lazy-lock.json
+	FormatPackerSnapshot Format = "packer"          // :PackerSnapshot JSON
+	FormatPackerCompiled Format = "packer_compiled" // plugin/packer_compiled.lua
+	FormatVimPlug        Format = "vim-plug"        // :PlugSnapshot output
+	FormatRocks          Format = "rocks"           // rocks.toml
+	FormatMiniDeps       Format = "mini.deps"       // mini-deps-snap
+)
+
+// lockFileNames are the lockfiles FindLockFile looks for in a config
+// directory, in order. vim-plug and packer snapshots have no fixed name and
+// must be passed explicitly.
+var lockFileNames = []string{
+	"lazy-lock.json",
+	"mini-deps-snap",
+	"rocks.toml",
+	filepath.Join("plugin", "packer_compiled.lua"),
+}
+
+// DetectFormat guesses the lockfile format from its name and content.
+func DetectFormat(path string, data []byte) (Format, error) {
+	base := filepath.Base(path)
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case base == "lazy-lock.json":
+		return FormatLazy, nil
+	case base == "rocks.toml" || strings.HasSuffix(base, ".toml"):
+		return FormatRocks, nil
+	case bytes.Contains(data, []byte("packer_plugins")):
+		return FormatPackerCompiled, nil
+	case bytes.Contains(data, []byte("g:plugs[")):
+		return FormatVimPlug, nil
+	case bytes.HasPrefix(trimmed, []byte("return")):
+		return FormatMiniDeps, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		// lazy-lock.json records a branch for every plugin; packer
+		// snapshots only record commits.
+		if bytes.Contains(data, []byte(`"branch"`)) {
+			return FormatLazy, nil
+		}
+		return FormatPackerSnapshot, nil
+	}
+	return "", fmt.Errorf("unrecognized lockfile format: %s", path)
+}
+
+// parseLockFile decodes a lockfile of any supported format into entries
+// keyed by plugin name.
+func parseLockFile(path string, data []byte) (Format, map[string]lockEntry, error) {
+	format, err := DetectFormat(path, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var entries map[string]lockEntry
+	switch format {
+	case FormatLazy, FormatPackerSnapshot:
+		err = json.Unmarshal(data, &entries)
+		if err != nil {
+			err = fmt.Errorf("parsing lockfile JSON: %w", err)
+		}
+	case FormatPackerCompiled:
+		entries, err = parsePackerCompiled(string(data))
+	case FormatVimPlug:
+		entries = parsePlugSnapshot(string(data))
+	case FormatRocks:
+		entries, err = parseRocks(string(data))
+	case FormatMiniDeps:
+		entries, err = parseMiniDepsSnap(string(data))
+	}
+	if err != nil {
+		return format, nil, fmt.Errorf("parsing %s lockfile: %w", format, err)
+	}
+	return format, entries, nil
+}
+
+// luaReturnedTable returns the table assigned to name in src, or the first
+// returned table when name is empty.
+func luaReturnedTable(src, name string) (*luaTable, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &luaParser{toks: toks}
+	for p.peek().kind != tokEOF {
+		t := p.next()
+		found := false
+		if name == "" {
+			found = t.kind == tokKeyword && t.text == "return"
+		} else if t.kind == tokName && t.text == name {
+			found = p.accept(tokSymbol, "=")
+		}
+		if found && p.is(tokSymbol, "{") {
+			return p.parseTableAt()
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no returned table")
+	}
+	return nil, fmt.Errorf("no %s table", name)
+}
+
+// parsePackerCompiled reads _G.packer_plugins from packer_compiled.lua. The
+// file has no commits, so they are read from each plugin's checkout.
+func parsePackerCompiled(src string) (map[string]lockEntry, error) {
+	tbl, err := luaReturnedTable(src, "packer_plugins")
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]lockEntry, len(tbl.fields))
+	for name, v := range tbl.fields {
+		if v.kind != luaTableValue {
+			continue
+		}
+		str := func(key string) string {
+			if f, ok := v.table.fields[key]; ok && f.kind == luaString {
+				return f.str
+			}
+			return ""
+		}
+		e := lockEntry{URL: str("url")}
+		if path := str("path"); path != "" {
+			e.Branch, e.Commit = gitHead(path)
+		}
+		entries[name] = e
+	}
+	return entries, nil
+}
+
+var plugCommitRe = regexp.MustCompile(`g:plugs\[['"]([^'"]+)['"]\]\.commit\s*=\s*['"]([0-9a-fA-F]+)['"]`)
+
+// parsePlugSnapshot reads the `let g:plugs['name'].commit = '...'` lines
+// written by :PlugSnapshot.
+func parsePlugSnapshot(src string) map[string]lockEntry {
+	entries := make(map[string]lockEntry)
+	for _, m := range plugCommitRe.FindAllStringSubmatch(src, -1) {
+		entries[m[1]] = lockEntry{Commit: m[2]}
+	}
+	return entries
+}
+
+// parseRocks reads the [plugins] table of rocks.toml. Entries installed
+// with rocks-git carry a `git` source and a `rev`; plain rocks only have a
+// version, which is looked up as a "v"-prefixed tag. Development ("scm")
+// rocks are not locked to anything and are left without a tag.
+func parseRocks(src string) (map[string]lockEntry, error) {
+	doc, err := parseTOML(src)
+	if err != nil {
+		return nil, err
+	}
+	plugins, _ := doc["plugins"].(map[string]any)
+	entries := make(map[string]lockEntry, len(plugins))
+	for name, v := range plugins {
+		e := lockEntry{Rock: true}
+		switch v := v.(type) {
+		case string:
+			e.Tag = rockTag(v)
+		case map[string]any:
+			git, _ := v["git"].(string)
+			rev, _ := v["rev"].(string)
+			version, _ := v["version"].(string)
+			switch {
+			case git != "":
+				e.URL, e.Commit, e.Rock = git, rev, false
+			case version != "":
+				e.Tag = rockTag(version)
+			}
+			if branch, ok := v["branch"].(string); ok {
+				e.Branch = branch
+			}
+		}
+		entries[name] = e
+	}
+	return entries, nil
+}
+
+// rockTag returns the tag a rock version was released as, or "" for a
+// development version.
+func rockTag(version string) string {
+	if version == "" || strings.HasPrefix(version, "scm") || strings.HasPrefix(version, "dev") {
+		return ""
+	}
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
+
+// parseMiniDepsSnap reads a mini.deps snapshot: a Lua file returning a
+// table from plugin name to commit.
+func parseMiniDepsSnap(src string) (map[string]lockEntry, error) {
+	tbl, err := luaReturnedTable(src, "")
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]lockEntry, len(tbl.fields))
+	for name, v := range tbl.fields {
+		if v.kind == luaString {
+			entries[name] = lockEntry{Commit: v.str}
+		}
+	}
+	return entries, nil
+}
+
+// gitHead returns the checked-out branch (empty when detached) and commit
+// of the repository at dir by reading .git directly.
+func gitHead(dir string) (branch, commit string) {
+	gitDir := gitDir(dir)
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !ok {
+		return "", ref
+	}
+	branch = strings.TrimPrefix(ref, "refs/heads/")
+
+	if sha, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return branch, strings.TrimSpace(string(sha))
+	}
+	packed, err := os.ReadFile(filepath.Join(gitDir, "packed-refs"))
+	if err != nil {
+		return branch, ""
+	}
+	for _, line := range strings.Split(string(packed), "\n") {
+		if sha, name, ok := strings.Cut(strings.TrimSpace(line), " "); ok && name == ref {
+			return branch, sha
+		}
+	}
+	return branch, ""
+}
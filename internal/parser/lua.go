@@ -0,0 +1,491 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file holds a small Lua front end: a complete tokenizer and an
+// expression parser that only evaluates what plugin specs need (string,
+// boolean, nil and number literals and table constructors). Everything
+// else — calls, identifiers, operators, function bodies — is parsed just
+// far enough to be skipped and becomes a luaOther value.
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokName
+	tokKeyword
+	tokString
+	tokNumber
+	tokSymbol
+)
+
+type token struct {
+	kind tokKind
+	text string // decoded value for strings
+	line int
+}
+
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true,
+	"end": true, "false": true, "for": true, "function": true, "goto": true,
+	"if": true, "in": true, "local": true, "nil": true, "not": true,
+	"or": true, "repeat": true, "return": true, "then": true, "true": true,
+	"until": true, "while": true,
+}
+
+// luaSymbols lists multi-character operators, longest first.
+var luaSymbols = []string{"...", "..", "==", "~=", "<=", ">=", "//", "::", "<<", ">>"}
+
+// tokenize splits Lua source into tokens, dropping whitespace and comments.
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	line := 1
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			i += 2
+			if level, ok := longBracket(src[i:]); ok {
+				body, n, err := readLong(src[i:], level)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unterminated comment", line)
+				}
+				line += strings.Count(body, "\n")
+				i += n
+				continue
+			}
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			s, n, err := readQuoted(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			toks = append(toks, token{kind: tokString, text: s, line: line})
+			line += strings.Count(src[i:i+n], "\n")
+			i += n
+		case c == '[':
+			if level, ok := longBracket(src[i:]); ok {
+				body, n, err := readLong(src[i:], level)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unterminated long string", line)
+				}
+				toks = append(toks, token{kind: tokString, text: strings.TrimPrefix(body, "\n"), line: line})
+				line += strings.Count(src[i:i+n], "\n")
+				i += n
+				continue
+			}
+			toks = append(toks, token{kind: tokSymbol, text: "[", line: line})
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isAlnum(src[i]) || src[i] == '.') {
+				if (src[i] == 'e' || src[i] == 'E' || src[i] == 'p' || src[i] == 'P') &&
+					i+1 < len(src) && (src[i+1] == '+' || src[i+1] == '-') {
+					i++
+				}
+				i++
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[start:i], line: line})
+		case isAlpha(c):
+			start := i
+			for i < len(src) && isAlnum(src[i]) {
+				i++
+			}
+			word := src[start:i]
+			kind := tokName
+			if luaKeywords[word] {
+				kind = tokKeyword
+			}
+			toks = append(toks, token{kind: kind, text: word, line: line})
+		default:
+			sym := string(c)
+			for _, s := range luaSymbols {
+				if strings.HasPrefix(src[i:], s) {
+					sym = s
+					break
+				}
+			}
+			toks = append(toks, token{kind: tokSymbol, text: sym, line: line})
+			i += len(sym)
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, line: line})
+	return toks, nil
+}
+
+// longBracket reports whether s starts with an opening long bracket
+// ("[[", "[=[", ...) and returns its level.
+func longBracket(s string) (int, bool) {
+	if len(s) < 2 || s[0] != '[' {
+		return 0, false
+	}
+	level := 0
+	for level+1 < len(s) && s[level+1] == '=' {
+		level++
+	}
+	if level+1 < len(s) && s[level+1] == '[' {
+		return level, true
+	}
+	return 0, false
+}
+
+// readLong reads a long bracket of the given level at the start of s and
+// returns its body and total length.
+func readLong(s string, level int) (string, int, error) {
+	open := level + 2
+	closer := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(s[open:], closer)
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated long bracket")
+	}
+	return s[open : open+end], open + end + len(closer), nil
+}
+
+// readQuoted decodes a single- or double-quoted string at the start of s.
+func readQuoted(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	i := 1
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'a', 'b', 'f', 'v':
+				// Control characters never matter for specs.
+			case 'z':
+				for i+1 < len(s) && strings.IndexByte(" \t\r\n", s[i+1]) >= 0 {
+					i++
+				}
+			case 'x':
+				if i+2 < len(s) {
+					if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+						b.WriteByte(byte(v))
+						i += 2
+					}
+				}
+			case 'u':
+				if end := strings.IndexByte(s[i:], '}'); i+1 < len(s) && s[i+1] == '{' && end > 0 {
+					if v, err := strconv.ParseUint(s[i+2:i+end], 16, 32); err == nil {
+						b.WriteRune(rune(v))
+					}
+					i += end
+				}
+			default:
+				if isDigit(e) {
+					j := i
+					for j < len(s) && j < i+3 && isDigit(s[j]) {
+						j++
+					}
+					v, _ := strconv.Atoi(s[i:j])
+					b.WriteByte(byte(v))
+					i = j - 1
+				} else {
+					b.WriteByte(e) // \\, \", \', \<newline>
+				}
+			}
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isAlpha(c byte) bool { return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isAlnum(c byte) bool { return isAlpha(c) || isDigit(c) }
+
+// ── Values ───────────────────────────────────────────────────────────────
+
+type luaKind int
+
+const (
+	luaOther luaKind = iota // anything that is not a literal
+	luaNil
+	luaBool
+	luaNumber
+	luaString
+	luaTableValue
+)
+
+type luaValue struct {
+	kind  luaKind
+	str   string // string literal or number text
+	b     bool
+	table *luaTable
+	line  int
+}
+
+// luaTable is a table constructor. Only string keys are kept for keyed
+// fields; positional fields keep their order.
+type luaTable struct {
+	array  []luaValue
+	fields map[string]luaValue
+	line   int
+}
+
+// luaParser walks a token slice. Parse errors are reported by panicking
+// with a luaSyntaxError, recovered in parseTableAt.
+type luaParser struct {
+	toks []token
+	pos  int
+}
+
+type luaSyntaxError struct {
+	line int
+	msg  string
+}
+
+func (e luaSyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+func (p *luaParser) peek() token { return p.toks[p.pos] }
+
+func (p *luaParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *luaParser) is(kind tokKind, text string) bool {
+	t := p.peek()
+	return t.kind == kind && t.text == text
+}
+
+func (p *luaParser) accept(kind tokKind, text string) bool {
+	if p.is(kind, text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *luaParser) expect(kind tokKind, text string) {
+	if !p.accept(kind, text) {
+		t := p.peek()
+		p.fail(t, fmt.Sprintf("expected %q, found %q", text, t.text))
+	}
+}
+
+func (p *luaParser) fail(t token, msg string) {
+	panic(luaSyntaxError{line: t.line, msg: msg})
+}
+
+// parseTableAt parses the table constructor starting at the current "{".
+func (p *luaParser) parseTableAt() (tbl *luaTable, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(luaSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = se
+		}
+	}()
+	v := p.parseTable()
+	return v.table, nil
+}
+
+var binaryOps = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "//": true, "%": true, "^": true,
+	"..": true, "==": true, "~=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"&": true, "|": true, "~": true, "<<": true, ">>": true,
+}
+
+// parseExpr parses a full expression. Anything beyond a lone literal or
+// table constructor collapses to luaOther.
+func (p *luaParser) parseExpr() luaValue {
+	v := p.parseUnary()
+	for {
+		t := p.peek()
+		isOp := (t.kind == tokSymbol && binaryOps[t.text]) ||
+			(t.kind == tokKeyword && (t.text == "and" || t.text == "or"))
+		if !isOp {
+			return v
+		}
+		p.next()
+		p.parseUnary()
+		v = luaValue{kind: luaOther, line: v.line}
+	}
+}
+
+func (p *luaParser) parseUnary() luaValue {
+	t := p.peek()
+	if (t.kind == tokKeyword && t.text == "not") ||
+		(t.kind == tokSymbol && (t.text == "-" || t.text == "#" || t.text == "~")) {
+		p.next()
+		p.parseUnary()
+		return luaValue{kind: luaOther, line: t.line}
+	}
+	return p.parsePrimary()
+}
+
+func (p *luaParser) parsePrimary() luaValue {
+	t := p.peek()
+	switch t.kind {
+	case tokString:
+		p.next()
+		return luaValue{kind: luaString, str: t.text, line: t.line}
+	case tokNumber:
+		p.next()
+		return luaValue{kind: luaNumber, str: t.text, line: t.line}
+	case tokKeyword:
+		switch t.text {
+		case "true", "false":
+			p.next()
+			return luaValue{kind: luaBool, b: t.text == "true", line: t.line}
+		case "nil":
+			p.next()
+			return luaValue{kind: luaNil, line: t.line}
+		case "function":
+			p.next()
+			p.skipFuncBody()
+			return luaValue{kind: luaOther, line: t.line}
+		}
+	case tokSymbol:
+		switch t.text {
+		case "{":
+			return p.parseTable()
+		case "...":
+			p.next()
+			return luaValue{kind: luaOther, line: t.line}
+		case "(":
+			p.next()
+			p.parseExpr()
+			p.expect(tokSymbol, ")")
+			p.parseSuffixes()
+			return luaValue{kind: luaOther, line: t.line}
+		}
+	case tokName:
+		p.next()
+		p.parseSuffixes()
+		return luaValue{kind: luaOther, line: t.line}
+	}
+	p.fail(t, fmt.Sprintf("unexpected %q", t.text))
+	return luaValue{}
+}
+
+// parseSuffixes consumes field accesses, indexing and calls after a
+// prefix expression.
+func (p *luaParser) parseSuffixes() {
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokSymbol && t.text == ".":
+			p.next()
+			p.next()
+		case t.kind == tokSymbol && t.text == "[":
+			p.next()
+			p.parseExpr()
+			p.expect(tokSymbol, "]")
+		case t.kind == tokSymbol && t.text == ":":
+			p.next()
+			p.next()
+			p.parseArgs()
+		case t.kind == tokSymbol && (t.text == "(" || t.text == "{"), t.kind == tokString:
+			p.parseArgs()
+		default:
+			return
+		}
+	}
+}
+
+func (p *luaParser) parseArgs() {
+	t := p.peek()
+	switch {
+	case t.kind == tokString:
+		p.next()
+	case t.kind == tokSymbol && t.text == "{":
+		p.parseTable()
+	case t.kind == tokSymbol && t.text == "(":
+		p.next()
+		for !p.accept(tokSymbol, ")") {
+			p.parseExpr()
+			if !p.accept(tokSymbol, ",") {
+				p.expect(tokSymbol, ")")
+				return
+			}
+		}
+	default:
+		p.fail(t, "expected call arguments")
+	}
+}
+
+func (p *luaParser) parseTable() luaValue {
+	open := p.next()
+	tbl := &luaTable{fields: make(map[string]luaValue), line: open.line}
+	for !p.accept(tokSymbol, "}") {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			p.fail(t, "unterminated table")
+		case t.kind == tokSymbol && t.text == "[":
+			p.next()
+			key := p.parseExpr()
+			p.expect(tokSymbol, "]")
+			p.expect(tokSymbol, "=")
+			val := p.parseExpr()
+			if key.kind == luaString {
+				tbl.fields[key.str] = val
+			}
+		case t.kind == tokName && p.toks[p.pos+1].kind == tokSymbol && p.toks[p.pos+1].text == "=":
+			p.pos += 2
+			tbl.fields[t.text] = p.parseExpr()
+		default:
+			tbl.array = append(tbl.array, p.parseExpr())
+		}
+		if !p.accept(tokSymbol, ",") && !p.accept(tokSymbol, ";") {
+			p.expect(tokSymbol, "}")
+			break
+		}
+	}
+	return luaValue{kind: luaTableValue, table: tbl, line: open.line}
+}
+
+// skipFuncBody consumes a parameter list and block up to the matching
+// "end". Block openers are counted rather than parsed: "function", "if",
+// "do" (which also covers while/for) and "repeat" open a block, "end" and
+// "until" close one.
+func (p *luaParser) skipFuncBody() {
+	depth := 1
+	for depth > 0 {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			p.fail(t, "unterminated function")
+		case t.kind != tokKeyword:
+		case t.text == "function" || t.text == "if" || t.text == "do" || t.text == "repeat":
+			depth++
+		case t.text == "end" || t.text == "until":
+			depth--
+		}
+	}
+}
@@ -1,33 +1,72 @@
 package parser
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 )
 
-// Plugin represents a single entry from lazy-lock.json.
+// Plugin represents a single entry from a plugin manager's lockfile.
 type Plugin struct {
-	Name   string 
-	Branch string 
-	Commit string 
-	Owner  string 
-	Repo   string 
+	Name   string `json:"name"`
+	Branch string `json:"branch"` // empty means the repository's default branch
+	Commit string `json:"commit"`
+	Host   string `json:"host,omitempty"` // e.g. "github.com", "gitlab.com"
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	// LockedTag is the version tag a lockfile records instead of a
+	// commit, as rocks.toml does for rocks installed from luarocks.
+	LockedTag string `json:"locked_tag,omitempty"`
+
+	// Metadata from the plugin's lazy.nvim spec, when one was found.
+	Version  string `json:"version,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Pin      bool   `json:"pin,omitempty"`
+	Dev      bool   `json:"dev,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Dir      string `json:"dir,omitempty"`
+	SpecFile string `json:"spec_file,omitempty"` // "path:line", relative to the config dir
+	// SpecCommit is the spec's `commit`, which lazy.nvim checks out
+	// instead of following the branch; Commit is what the lockfile holds.
+	SpecCommit string `json:"spec_commit,omitempty"`
+
+	// ResolvedBy is the source of Host/Owner/Repo; see ResolvedLockfile.
+	ResolvedBy string `json:"resolved_by,omitempty"`
+
+	// Profiles lists the NVIM_APPNAME profiles that lock the plugin at this
+	// commit, when several profiles are analyzed together.
+	Profiles []string `json:"profiles,omitempty"`
 }
 
+// DefaultHost is where plugins referenced by a bare "owner/repo" live.
+const DefaultHost = "github.com"
+
 type lockEntry struct {
 	Branch string `json:"branch"`
 	Commit string `json:"commit"`
+	URL    string `json:"-"` // clone URL or "owner/repo", when the lockfile has one
+	Tag    string `json:"-"` // version tag, when the lockfile has no commit
+	Rock   bool   `json:"-"` // a luarocks package, named after the rock
+}
+
+// Locked returns the ref the plugin is locked at: its commit, or the
+// version tag when the lockfile records no commit.
+func (p Plugin) Locked() string {
+	if p.Commit != "" {
+		return p.Commit
+	}
+	return p.LockedTag
 }
 
-// FindLockFile locates the lazy-lock.json file. It checks in order:
+// FindLockFile locates the lockfile of the appName profile (see AppName).
+// It checks in order:
 // 1. The provided path (if non-empty)
-// 2. $XDG_CONFIG_HOME/nvim/lazy-lock.json
-// 3. ~/.config/nvim/lazy-lock.json
-func FindLockFile(path string) (string, error) {
+// 2. $XDG_CONFIG_HOME/<appName>
+// 3. ~/.config/<appName>
+// In each directory the first of lockFileNames that exists is used.
+func FindLockFile(path, appName string) (string, error) {
 	if path != "" {
 		if _, err := os.Stat(path); err != nil {
 			return "", fmt.Errorf("lockfile not found at %s: %w", path, err)
@@ -35,13 +74,11 @@ func FindLockFile(path string) (string, error) {
 		return path, nil
 	}
 
-	candidates := []string{}
-	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
-		candidates = append(candidates, filepath.Join(xdg, "nvim", "lazy-lock.json"))
-	}
-	home, err := os.UserHomeDir()
-	if err == nil {
-		candidates = append(candidates, filepath.Join(home, ".config", "nvim", "lazy-lock.json"))
+	var candidates []string
+	for _, home := range ConfigHomes() {
+		for _, name := range lockFileNames {
+			candidates = append(candidates, filepath.Join(home, appName, name))
+		}
 	}
 
 	for _, c := range candidates {
@@ -50,107 +87,185 @@ func FindLockFile(path string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("lazy-lock.json not found; tried: %v", candidates)
+	return "", fmt.Errorf("no lockfile found; tried: %v", candidates)
 }
 
-func inferGitHubRepo(name string, overrides map[string]string) (owner, repo string) {
+func inferRepo(name string, overrides map[string]string) (host, owner, repo string) {
 	// Check overrides first (from config scan)
 	if override, ok := overrides[name]; ok {
-		parts := strings.SplitN(override, "/", 2)
-		if len(parts) == 2 {
-			return parts[0], parts[1]
+		if host, owner, repo, ok := splitOverride(override); ok {
+			return host, owner, repo
 		}
 	}
 
-
 	repo = name
 	owner = name
 	// Strip common suffixes for the owner guess
 	owner = strings.TrimSuffix(owner, ".nvim")
 	owner = strings.TrimSuffix(owner, ".lua")
 
-	return owner, repo
+	return DefaultHost, owner, repo
 }
 
-// Parse reads and parses a lazy-lock.json file, returning a slice of Plugins
-// with inferred GitHub owner/repo information.
+// splitOverride splits "owner/repo" or "host/owner.../repo" as produced by
+// ScanConfig. A leading segment containing a dot is a host; anything left
+// between it and the repo is the owner, which may include GitLab subgroups.
+func splitOverride(s string) (host, owner, repo string, ok bool) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 {
+		return "", "", "", false
+	}
+	host = DefaultHost
+	if len(parts) > 2 && strings.Contains(parts[0], ".") {
+		host, parts = parts[0], parts[1:]
+	}
+	if len(parts) < 2 {
+		return "", "", "", false
+	}
+	return host, strings.Join(parts[:len(parts)-1], "/"), parts[len(parts)-1], true
+}
+
+// Parse reads and parses a lockfile in any format DetectFormat knows,
+// returning a slice of Plugins with inferred owner/repo information.
 // configDir is the path to the neovim configuration directory (e.g. ~/.config/nvim).
-// If empty, it defaults to ~/.config/nvim.
+// If empty, it defaults to the config directory of the active profile.
 func Parse(lockPath string, configDir string) ([]Plugin, error) {
+	plugins, _, err := ParseWithDiagnostics(lockPath, configDir)
+	return plugins, err
+}
+
+// ParseWithDiagnostics is Parse, also returning the problems found while
+// mapping lockfile entries to the specs in configDir.
+func ParseWithDiagnostics(lockPath string, configDir string) ([]Plugin, []Diagnostic, error) {
+	return ParseProfile(Profile{Name: AppName(), ConfigDir: configDir, LockFile: lockPath})
+}
+
+// ParseProfile is ParseWithDiagnostics for prof; its name locates the
+// default config directory and the plugin checkouts.
+func ParseProfile(prof Profile) ([]Plugin, []Diagnostic, error) {
+	lockPath, configDir, appName := prof.LockFile, prof.ConfigDir, prof.Name
 	data, err := os.ReadFile(lockPath)
 	if err != nil {
-		return nil, fmt.Errorf("reading lockfile: %w", err)
+		return nil, nil, fmt.Errorf("reading lockfile: %w", err)
 	}
 
-	var entries map[string]lockEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return nil, fmt.Errorf("parsing lockfile JSON: %w", err)
+	_, entries, err := parseLockFile(lockPath, data)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Scan config for plugin definitions
 	if configDir == "" {
-		home, _ := os.UserHomeDir()
-		configDir = filepath.Join(home, ".config", "nvim")
+		configDir = DefaultConfigDir(appName)
 	}
 
-	overrides, err := ScanConfig(configDir)
+	specs, diags, err := ScanSpecs(configDir)
 	if err != nil {
-		// 
+		diags = append(diags, Diagnostic{File: configDir, Message: fmt.Sprintf("scanning config: %v", err)})
 	}
 
 	plugins := make([]Plugin, 0, len(entries))
 	for name, entry := range entries {
-		owner, repo := inferGitHubRepo(name, overrides)
-		plugins = append(plugins, Plugin{
-			Name:   name,
-			Branch: entry.Branch,
-			Commit: entry.Commit,
-			Owner:  owner,
-			Repo:   repo,
-		})
+		if entry.Commit == "" && entry.Tag == "" {
+			diags = append(diags, Diagnostic{File: lockPath, Message: fmt.Sprintf(
+				"no locked commit or version for %q; skipped", name)})
+			continue
+		}
+		p := Plugin{Name: name, Branch: entry.Branch, Commit: entry.Commit, LockedTag: entry.Tag}
+		if spec, ok := specs[name]; ok {
+			p.applySpec(spec)
+		}
+		if d, ok := p.resolve(entry, appName); ok {
+			diags = append(diags, d)
+		}
+		if p.Branch == "" {
+			// Lockfiles other than lazy-lock.json may not record a branch;
+			// follow the one the checkout tracks, if any.
+			p.Branch = checkoutBranch(checkoutDirs(p.Name, appName, p.Dir))
+		}
+		plugins = append(plugins, p)
 	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
 
-	return plugins, nil
+	return plugins, sortedDiagnostics(diags), nil
 }
 
-func ScanConfig(root string) (map[string]string, error) {
-	overrides := make(map[string]string)
+// resolve sets p's location from the most trusted source that has one, in
+// the order of the Resolved* constants. A plugin whose location had to be
+// guessed returns a diagnostic.
+func (p *Plugin) resolve(entry lockEntry, appName string) (Diagnostic, bool) {
+	set := func(host, owner, repo, source string) {
+		p.Host, p.Owner, p.Repo, p.ResolvedBy = host, owner, repo, source
+	}
+	if host, owner, repo, ok := entryLocation(entry); ok {
+		set(host, owner, repo, ResolvedLockfile)
+		return Diagnostic{}, false
+	}
+	if p.Owner != "" { // from applySpec
+		p.ResolvedBy = ResolvedSpec
+		return Diagnostic{}, false
+	}
+	if host, owner, repo, ok := checkoutLocation(checkoutDirs(p.Name, appName, p.Dir)); ok {
+		set(host, owner, repo, ResolvedCheckout)
+		return Diagnostic{}, false
+	}
+	if known, ok := builtinSpecs[p.Name]; ok {
+		set(DefaultHost, known[0], known[1], ResolvedBuiltin)
+		return Diagnostic{}, false
+	}
+	host, owner, repo := inferRepo(p.Name, nil)
+	set(host, owner, repo, ResolvedGuess)
+	if entry.Rock {
+		return Diagnostic{Message: fmt.Sprintf(
+			"luarocks package %q has no known repository; guessed %s/%s from the rock name", p.Name, owner, repo)}, true
+	}
+	return Diagnostic{Message: fmt.Sprintf(
+		"no spec or checkout found for %q; guessed %s/%s", p.Name, owner, repo)}, true
+}
 
-	re := regexp.MustCompile(`["']([a-zA-Z0-9_\-\.]+\/[a-zA-Z0-9_\-\.]+)["']`)
+// entryLocation returns the repository recorded in the lockfile itself.
+func entryLocation(e lockEntry) (host, owner, repo string, ok bool) {
+	if shortSpecRe.MatchString(e.URL) {
+		owner, repo, _ := strings.Cut(e.URL, "/")
+		return DefaultHost, owner, repo, true
+	}
+	return ParseRemoteURL(e.URL)
+}
 
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // skip unreadable files
-		}
-		if d.IsDir() {
-			if d.Name() == ".git" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if !strings.HasSuffix(path, ".lua") {
-			return nil
-		}
+// builtinSpecs covers plugins that are usually not declared as specs.
+var builtinSpecs = map[string][2]string{
+	"lazy.nvim": {"folke", "lazy.nvim"},
+}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return nil
-		}
+// applySpec copies the repository location and spec metadata onto p. A
+// spec branch replaces the lockfile's, since it is what updates follow.
+func (p *Plugin) applySpec(s Spec) {
+	if host, owner, repo, ok := s.Location(); ok {
+		p.Host, p.Owner, p.Repo = host, owner, repo
+	}
+	if s.Branch != "" {
+		p.Branch = s.Branch
+	}
+	p.Version = s.Version
+	p.Tag = s.Tag
+	p.SpecCommit = s.Commit
+	p.Pin = s.Pin
+	p.Dev = s.Dev
+	p.Dir = s.Dir
+	p.Disabled = s.Enabled != nil && !*s.Enabled
+	p.SpecFile = fmt.Sprintf("%s:%d", s.File, s.Line)
+}
 
-		matches := re.FindAllStringSubmatch(string(content), -1)
-		for _, m := range matches {
-			if len(m) > 1 {
-				full := m[1]
-				parts := strings.Split(full, "/")
-				if len(parts) == 2 {
-					repo := parts[1]
-			
-					overrides[repo] = full
-				}
-			}
+// ScanConfig scans Lua files under root for plugin specs and returns a map
+// from plugin name to "owner/repo", or "host/owner/repo" for plugins cloned
+// from a host other than GitHub. See ScanSpecs for the full spec data.
+func ScanConfig(root string) (map[string]string, error) {
+	specs, _, err := ScanSpecs(root)
+	overrides := make(map[string]string, len(specs))
+	for name, s := range specs {
+		if loc, ok := s.override(); ok {
+			overrides[name] = loc
 		}
-		return nil
-	})
-
+	}
 	return overrides, err
 }
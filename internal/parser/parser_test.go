@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -88,7 +90,7 @@ func TestFindLockFile_WithExplicitPath(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	found, err := FindLockFile(path)
+	found, err := FindLockFile(path, "nvim")
 	if err != nil {
 		t.Fatalf("FindLockFile failed: %v", err)
 	}
@@ -98,8 +100,477 @@ func TestFindLockFile_WithExplicitPath(t *testing.T) {
 }
 
 func TestFindLockFile_NotFound(t *testing.T) {
-	_, err := FindLockFile("/nonexistent/path/lazy-lock.json")
+	_, err := FindLockFile("/nonexistent/path/lazy-lock.json", "nvim")
 	if err == nil {
 		t.Error("expected error for nonexistent path")
 	}
 }
+
+func TestScanConfigURLs(t *testing.T) {
+	dir := t.TempDir()
+	luaContent := `
+	return {
+	  { url = "https://gitlab.com/group/sub/gl-plugin.nvim.git" },
+	  { url = "git@codeberg.org:someone/cb-plugin.nvim" },
+	  { url = "https://github.com/folke/tokyonight.nvim" },
+	}
+	`
+	if err := os.WriteFile(filepath.Join(dir, "plugins.lua"), []byte(luaContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides, err := ScanConfig(dir)
+	if err != nil {
+		t.Fatalf("ScanConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name, host, owner, repo string
+	}{
+		{"gl-plugin.nvim", "gitlab.com", "group/sub", "gl-plugin.nvim"},
+		{"cb-plugin.nvim", "codeberg.org", "someone", "cb-plugin.nvim"},
+		{"tokyonight.nvim", "github.com", "folke", "tokyonight.nvim"},
+	}
+	for _, tt := range tests {
+		host, owner, repo := inferRepo(tt.name, overrides)
+		if host != tt.host || owner != tt.owner || repo != tt.repo {
+			t.Errorf("%s: got %s %s/%s, want %s %s/%s", tt.name, host, owner, repo, tt.host, tt.owner, tt.repo)
+		}
+	}
+}
+
+func TestScanSpecs(t *testing.T) {
+	dir := t.TempDir()
+	luaContent := `
+	-- "commented/out.nvim" must not be picked up
+	--[[ { "block/comment.nvim" } ]]
+	local docs = "see https://github.com/not/a-spec for details"
+	dofile("lua/extra.lua")
+	local util = require "my/util"
+	return {
+	  { "folke/tokyonight.nvim", name = "tokyo", version = "^4", pin = true },
+	  {
+	    "nvim-telescope/telescope.nvim",
+	    tag = "0.1.8",
+	    enabled = false,
+	    dependencies = { "nvim-lua/plenary.nvim", { "nvim-tree/nvim-web-devicons", dev = true } },
+	    config = function() require("telescope").setup({ "x/y" }) end,
+	  },
+	  { dir = "~/src/my-plugin.nvim" },
+	}
+	`
+	if err := os.WriteFile(filepath.Join(dir, "plugins.lua"), []byte(luaContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	specs, diags, err := ScanSpecs(dir)
+	if err != nil {
+		t.Fatalf("ScanSpecs failed: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+
+	for _, name := range []string{"out.nvim", "comment.nvim", "a-spec", "tokyonight.nvim", "y", "extra.lua", "util"} {
+		if _, ok := specs[name]; ok {
+			t.Errorf("did not expect a spec for %q", name)
+		}
+	}
+
+	tokyo := specs["tokyo"]
+	if tokyo.Short != "folke/tokyonight.nvim" || tokyo.Version != "^4" || !tokyo.Pin {
+		t.Errorf("tokyo: got %+v", tokyo)
+	}
+	tele := specs["telescope.nvim"]
+	if tele.Tag != "0.1.8" || tele.Enabled == nil || *tele.Enabled || tele.Dependency {
+		t.Errorf("telescope: got %+v", tele)
+	}
+	if p := specs["plenary.nvim"]; p.Short != "nvim-lua/plenary.nvim" || !p.Dependency {
+		t.Errorf("plenary: got %+v", p)
+	}
+	if d := specs["nvim-web-devicons"]; !d.Dev || !d.Dependency {
+		t.Errorf("devicons: got %+v", d)
+	}
+	if d := specs["my-plugin.nvim"]; d.Dir != "~/src/my-plugin.nvim" {
+		t.Errorf("my-plugin: got %+v", d)
+	}
+}
+
+func TestParseWithDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	lock := `{
+  "foo.nvim": { "branch": "main", "commit": "1111111111111111111111111111111111111111" },
+  "bar.nvim": { "branch": "main", "commit": "2222222222222222222222222222222222222222" }
+}`
+	lockPath := filepath.Join(dir, "lazy-lock.json")
+	if err := os.WriteFile(lockPath, []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.lua": `return { { "alice/foo.nvim", branch = "dev", commit = "abc1234", version = "*" } }`,
+		"b.lua": `return { { "bob/foo.nvim" } }`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plugins, diags, err := ParseWithDiagnostics(lockPath, dir)
+	if err != nil {
+		t.Fatalf("ParseWithDiagnostics failed: %v", err)
+	}
+	if len(plugins) != 2 || plugins[0].Name != "bar.nvim" || plugins[1].Name != "foo.nvim" {
+		t.Fatalf("unexpected plugins: %+v", plugins)
+	}
+	if foo := plugins[1]; foo.Owner != "alice" || foo.Version != "*" || foo.Branch != "dev" || foo.SpecCommit != "abc1234" || foo.SpecFile != "a.lua:1" {
+		t.Errorf("foo.nvim: got %+v", foo)
+	}
+
+	// One diagnostic for the conflicting foo.nvim spec, one for the guessed bar.nvim.
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diags)
+	}
+	if !strings.Contains(diags[0].Message, "bar.nvim") || diags[1].File != "b.lua" {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url, host, owner, repo string
+		ok                     bool
+	}{
+		{"https://github.com/folke/lazy.nvim.git", "github.com", "folke", "lazy.nvim", true},
+		{"https://user@GitLab.com/group/sub/proj", "gitlab.com", "group/sub", "proj", true},
+		{"ssh://git@git.example.com:2222/team/tool.git", "git.example.com", "team", "tool", true},
+		{"git@codeberg.org:someone/plugin.nvim", "codeberg.org", "someone", "plugin.nvim", true},
+		{"folke/lazy.nvim", "", "", "", false},
+		{"https://github.com/only-owner", "", "", "", false},
+	}
+	for _, tt := range tests {
+		host, owner, repo, ok := ParseRemoteURL(tt.url)
+		if ok != tt.ok || host != tt.host || owner != tt.owner || repo != tt.repo {
+			t.Errorf("%s: got %q %q/%q %v", tt.url, host, owner, repo, ok)
+		}
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	doc, err := parseTOML(`[bundles.lsp]
+items = [
+  "nvim-lspconfig", # servers
+  'mason.nvim'
+
+]
+empty = [
+]
+nested = [[1, 2], [
+  3,
+]]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lsp := doc["bundles"].(map[string]any)["lsp"].(map[string]any)
+	if got := fmt.Sprint(lsp["items"], lsp["empty"], lsp["nested"]); got != "[nvim-lspconfig mason.nvim] [] [[1 2] [3]]" {
+		t.Errorf("got %s", got)
+	}
+
+	for src, want := range map[string]string{
+		"a = [\n  1,\n  2\n":      "line 4",
+		"a = \"x\n\"":             "line 1: unterminated string",
+		"a = 1\nb = [1 2]":        "line 2: expected , or ]",
+		"a = 'x\n'":               "line 1: unterminated string",
+		"[t]\na = { b = 1 }\nc =": "line 3: expected value",
+	} {
+		if _, err := parseTOML(src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want %q", src, err, want)
+		}
+	}
+}
+
+func TestParseLockFormats(t *testing.T) {
+	const sha1, sha2 = "1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222"
+
+	// A plugin checkout for packer_compiled.lua, which has no commits.
+	checkout := t.TempDir()
+	for path, content := range map[string]string{
+		".git/HEAD":        "ref: refs/heads/dev\n",
+		".git/packed-refs": "# pack-refs with: peeled\n" + sha2 + " refs/heads/dev\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(checkout, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(checkout, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		file    string
+		content string
+		format  Format
+		want    map[string]Plugin
+	}{
+		{
+			"snapshot.json",
+			`{"telescope.nvim": {"commit": "` + sha1 + `"}}`,
+			FormatPackerSnapshot,
+			map[string]Plugin{"telescope.nvim": {Commit: sha1, Owner: "nvim-telescope", Repo: "telescope.nvim"}},
+		},
+		{
+			"packer_compiled.lua",
+			`local no_errors, error_msg = pcall(function()
+_G.packer_plugins = {
+  ["gl.nvim"] = { loaded = true, path = "` + checkout + `", url = "https://gitlab.com/group/gl.nvim" },
+}
+end)`,
+			FormatPackerCompiled,
+			map[string]Plugin{"gl.nvim": {Commit: sha2, Branch: "dev", Host: "gitlab.com", Owner: "group", Repo: "gl.nvim"}},
+		},
+		{
+			"plug.snapshot",
+			"\" Generated by vim-plug\nsilent! let g:plugs['fugitive'].commit = '" + sha1 + "'\nPlugUpdate!\n",
+			FormatVimPlug,
+			map[string]Plugin{"fugitive": {Commit: sha1, Owner: "tpope", Repo: "vim-fugitive"}},
+		},
+		{
+			"rocks.toml",
+			`[config]
+colorscheme = "kanagawa" # comment
+[plugins]
+"rocks.nvim" = "2.31.0"
+neorg = { version = "8.0.0", opt = true }
+"lz.n" = "scm"
+[plugins."nvim-treesitter"]
+git = "nvim-treesitter/nvim-treesitter"
+rev = "` + sha1 + `"
+[bundles.lsp]
+items = [
+  "nvim-lspconfig",
+]
+`,
+			FormatRocks,
+			map[string]Plugin{
+				"rocks.nvim":      {LockedTag: "v2.31.0", Owner: "rocks", Repo: "rocks.nvim"},
+				"neorg":           {LockedTag: "v8.0.0", Owner: "neorg", Repo: "neorg"},
+				"nvim-treesitter": {Commit: sha1, Owner: "nvim-treesitter", Repo: "nvim-treesitter"},
+			},
+		},
+		{
+			"mini-deps-snap",
+			"return {\n  [\"mini.nvim\"] = \"" + sha1 + "\",\n  plenary = \"" + sha2 + "\",\n}\n",
+			FormatMiniDeps,
+			map[string]Plugin{
+				"mini.nvim": {Commit: sha1, Owner: "echasnovski", Repo: "mini.nvim"},
+				"plenary":   {Commit: sha2, Owner: "nvim-lua", Repo: "plenary.nvim"},
+			},
+		},
+	}
+
+	config := t.TempDir()
+	specs := `
+MiniDeps.add("echasnovski/mini.nvim")
+MiniDeps.add({ source = "nvim-lua/plenary.nvim", name = "plenary" })
+use "nvim-telescope/telescope.nvim"
+`
+	if err := os.WriteFile(filepath.Join(config, "init.lua"), []byte(specs), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(config, "plugins.vim"), []byte("Plug 'tpope/vim-fugitive', { 'as': 'fugitive' }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, tt.file)
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if format, err := DetectFormat(path, []byte(tt.content)); err != nil || format != tt.format {
+			t.Errorf("%s: detected %q, %v; want %q", tt.file, format, err, tt.format)
+		}
+
+		plugins, err := Parse(path, config)
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", tt.file, err)
+		}
+		if len(plugins) != len(tt.want) {
+			t.Fatalf("%s: got %d plugins, want %d: %+v", tt.file, len(plugins), len(tt.want), plugins)
+		}
+		for _, p := range plugins {
+			want, ok := tt.want[p.Name]
+			if !ok {
+				t.Errorf("%s: unexpected plugin %q", tt.file, p.Name)
+				continue
+			}
+			if p.Commit != want.Commit || p.LockedTag != want.LockedTag || p.Branch != want.Branch || p.Owner != want.Owner || p.Repo != want.Repo ||
+				(want.Host != "" && p.Host != want.Host) {
+				t.Errorf("%s: %s = %+v, want %+v", tt.file, p.Name, p, want)
+			}
+		}
+	}
+}
+
+func TestFindLockFileSearchesFormats(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	if err := os.MkdirAll(filepath.Join(xdg, "nvim"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(xdg, "nvim", "rocks.toml")
+	if err := os.WriteFile(want, []byte("[plugins]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindLockFile("", "nvim")
+	if err != nil || got != want {
+		t.Errorf("FindLockFile = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestFindAndMergeProfiles(t *testing.T) {
+	const sha1, sha2 = "1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222"
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", t.TempDir())
+
+	locks := map[string]string{
+		"nvim":         `{"plenary.nvim": {"branch": "master", "commit": "` + sha1 + `"}, "foo.nvim": {"branch": "main", "commit": "` + sha1 + `"}}`,
+		"nvim-lite":    `{"plenary.nvim": {"branch": "master", "commit": "` + sha1 + `"}}`,
+		"nvim-work":    `{"plenary.nvim": {"branch": "main", "commit": "` + sha1 + `"}}`,
+		"nvim-pinned":  `{"plenary.nvim": {"branch": "master", "commit": "` + sha1 + `"}}`,
+		"nvim-minimal": `{"plenary.nvim": {"branch": "master", "commit": "` + sha2 + `"}}`,
+		"nvim-broken":  `{"plenary.nvim": `,
+	}
+	specs := map[string]string{
+		"nvim-pinned": `return { { "nvim-lua/plenary.nvim", version = "^1" } }`,
+	}
+	for name, lock := range locks {
+		dir := filepath.Join(xdg, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "lazy-lock.json"), []byte(lock), 0644); err != nil {
+			t.Fatal(err)
+		}
+		spec, ok := specs[name]
+		if !ok {
+			spec = `return { { "nvim-lua/plenary.nvim" }, { "someone/foo.nvim" } }`
+		}
+		if err := os.WriteFile(filepath.Join(dir, "init.lua"), []byte(spec), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(xdg, "git"), 0755); err != nil { // not a Neovim config
+		t.Fatal(err)
+	}
+	backup := filepath.Join(xdg, "nvim.bak") // a lockfile without an init file
+	if err := os.MkdirAll(backup, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backup, "lazy-lock.json"), []byte(locks["nvim"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := FindProfiles()
+	if err != nil {
+		t.Fatalf("FindProfiles failed: %v", err)
+	}
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, " ") != "nvim nvim-broken nvim-lite nvim-minimal nvim-pinned nvim-work" {
+		t.Fatalf("profiles: got %v", names)
+	}
+
+	plugins, diags, err := ParseProfiles(profiles)
+	if err != nil {
+		t.Fatalf("ParseProfiles failed: %v", err)
+	}
+	if len(diags) != 1 || diags[0].File != "nvim-broken" {
+		t.Errorf("diagnostics: got %v, want one for nvim-broken", diags)
+	}
+	var got []string
+	for _, p := range plugins {
+		got = append(got, p.Name+"@"+p.Commit[:1]+"="+strings.Join(p.Profiles, ","))
+	}
+	// A different branch or version constraint keeps a profile apart even
+	// on the same commit.
+	want := "foo.nvim@1=nvim plenary.nvim@1=nvim,nvim-lite plenary.nvim@2=nvim-minimal plenary.nvim@1=nvim-pinned plenary.nvim@1=nvim-work"
+	if strings.Join(got, " ") != want {
+		t.Errorf("merged plugins:\n got %s\nwant %s", strings.Join(got, " "), want)
+	}
+
+	lock, err := FindLockFile("", "nvim-work")
+	if err != nil || lock != filepath.Join(xdg, "nvim-work", "lazy-lock.json") {
+		t.Errorf("FindLockFile for nvim-work: got %q, %v", lock, err)
+	}
+}
+
+func TestResolveFromCheckout(t *testing.T) {
+	const sha = "1111111111111111111111111111111111111111"
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	t.Setenv("NVIM_APPNAME", "nvim")
+
+	writeGitConfig := func(dir, config string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGitConfig(filepath.Join(data, "nvim", "lazy", "blink.cmp"), `[core]
+	bare = false
+[remote "upstream"]
+	url = https://github.com/someone/fork.git
+[remote "origin"]
+	url = https://github.com/Saghen/blink.cmp.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+`)
+	local := filepath.Join(t.TempDir(), "my-plugin")
+	writeGitConfig(local, "[remote \"mine\"]\n\turl = git@gitlab.com:me/my-plugin.nvim.git\n")
+
+	dir := t.TempDir()
+	lock := `{
+  "blink.cmp": { "branch": "main", "commit": "` + sha + `" },
+  "my-plugin": { "branch": "main", "commit": "` + sha + `" },
+  "tokyonight.nvim": { "branch": "main", "commit": "` + sha + `" },
+  "lazy.nvim": { "branch": "main", "commit": "` + sha + `" },
+  "mystery.nvim": { "branch": "main", "commit": "` + sha + `" }
+}`
+	lockPath := filepath.Join(dir, "lazy-lock.json")
+	if err := os.WriteFile(lockPath, []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	specs := `return { { "folke/tokyonight.nvim" }, { dir = "` + local + `" } }`
+	if err := os.WriteFile(filepath.Join(dir, "init.lua"), []byte(specs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, diags, err := ParseWithDiagnostics(lockPath, dir)
+	if err != nil {
+		t.Fatalf("ParseWithDiagnostics failed: %v", err)
+	}
+	want := map[string]string{
+		"blink.cmp":       "checkout github.com/Saghen/blink.cmp",
+		"my-plugin":       "checkout gitlab.com/me/my-plugin.nvim",
+		"tokyonight.nvim": "spec github.com/folke/tokyonight.nvim",
+		"lazy.nvim":       "builtin github.com/folke/lazy.nvim",
+		"mystery.nvim":    "guess github.com/mystery/mystery.nvim",
+	}
+	for _, p := range plugins {
+		got := p.ResolvedBy + " " + p.Host + "/" + p.Owner + "/" + p.Repo
+		if got != want[p.Name] {
+			t.Errorf("%s: got %q, want %q", p.Name, got, want[p.Name])
+		}
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, "mystery.nvim") {
+		t.Errorf("expected one guess diagnostic, got %v", diags)
+	}
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Profile is one Neovim configuration, selected at runtime with
+// NVIM_APPNAME (e.g. "nvim-work" reads ~/.config/nvim-work).
+type Profile struct {
+	Name      string `json:"name"`
+	ConfigDir string `json:"config_dir"`
+	LockFile  string `json:"lockfile"`
+}
+
+// AppName returns the active profile name: $NVIM_APPNAME, or "nvim".
+func AppName() string {
+	if name := os.Getenv("NVIM_APPNAME"); name != "" {
+		return name
+	}
+	return "nvim"
+}
+
+// ConfigHomes returns the directories Neovim configs live in:
+// $XDG_CONFIG_HOME when set, then ~/.config.
+func ConfigHomes() []string {
+	var homes []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		homes = append(homes, xdg)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		homes = append(homes, filepath.Join(home, ".config"))
+	}
+	return homes
+}
+
+// DefaultConfigDir returns the config directory of the appName profile.
+func DefaultConfigDir(appName string) string {
+	homes := ConfigHomes()
+	for _, h := range homes {
+		dir := filepath.Join(h, appName)
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	if len(homes) == 0 {
+		return appName
+	}
+	return filepath.Join(homes[0], appName)
+}
+
+// initFiles mark a directory as a Neovim config.
+var initFiles = []string{"init.lua", "init.vim"}
+
+// FindProfiles returns every config directory directly under the config
+// homes that has an init file and a lockfile, sorted by name. A profile
+// present in both homes is taken from the first.
+func FindProfiles() ([]Profile, error) {
+	seen := make(map[string]bool)
+	var profiles []Profile
+	for _, home := range ConfigHomes() {
+		entries, err := os.ReadDir(home)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() || seen[e.Name()] {
+				continue
+			}
+			dir := filepath.Join(home, e.Name())
+			if !hasAny(dir, initFiles) {
+				continue
+			}
+			for _, name := range lockFileNames {
+				lock := filepath.Join(dir, name)
+				if _, err := os.Stat(lock); err == nil {
+					seen[e.Name()] = true
+					profiles = append(profiles, Profile{Name: e.Name(), ConfigDir: dir, LockFile: lock})
+					break
+				}
+			}
+		}
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no Neovim profiles with a lockfile found under %v", ConfigHomes())
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// hasAny reports whether dir holds any of names.
+func hasAny(dir string, names []string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProfiles parses every profile and merges the results with
+// MergeProfiles. Diagnostic paths are prefixed with the profile name. A
+// profile that fails to parse is skipped with a diagnostic; it is an error
+// only when every profile fails.
+func ParseProfiles(profiles []Profile) ([]Plugin, []Diagnostic, error) {
+	byProfile := make(map[string][]Plugin, len(profiles))
+	var diags []Diagnostic
+	var firstErr error
+	for _, prof := range profiles {
+		plugins, profDiags, err := ParseProfile(prof)
+		if err != nil {
+			err = fmt.Errorf("profile %s: %w", prof.Name, err)
+			firstErr = cmp.Or(firstErr, err)
+			diags = append(diags, Diagnostic{File: prof.Name, Message: fmt.Sprintf("%v; skipped", err)})
+			continue
+		}
+		byProfile[prof.Name] = plugins
+		for _, d := range profDiags {
+			if d.File == "" || d.File == prof.LockFile {
+				d.File = prof.Name
+			} else if !filepath.IsAbs(d.File) {
+				d.File = filepath.Join(prof.Name, d.File)
+			}
+			diags = append(diags, d)
+		}
+	}
+	if len(byProfile) == 0 && firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return MergeProfiles(byProfile), sortedDiagnostics(diags), nil
+}
+
+// MergeProfiles combines the plugins of several profiles so that each
+// repository is listed once per locked commit, branch and spec constraint
+// (version, tag, pin and commit). Profiles on the merged Plugin lists every
+// profile that agrees on all of them; the rest of its fields come from the
+// first of them by name.
+func MergeProfiles(byProfile map[string][]Plugin) []Plugin {
+	names := make([]string, 0, len(byProfile))
+	for name := range byProfile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	index := make(map[string]int)
+	var merged []Plugin
+	for _, prof := range names {
+		for _, p := range byProfile[prof] {
+			key := strings.Join([]string{
+				strings.ToLower(p.Host), strings.ToLower(p.Owner), strings.ToLower(p.Repo),
+				p.Locked(), p.Branch, p.Version, p.Tag, p.SpecCommit, strconv.FormatBool(p.Pin),
+			}, "\x00")
+			if i, ok := index[key]; ok {
+				merged[i].Profiles = append(merged[i].Profiles, prof)
+				continue
+			}
+			p.Profiles = []string{prof}
+			index[key] = len(merged)
+			merged = append(merged, p)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Name != merged[j].Name {
+			return merged[i].Name < merged[j].Name
+		}
+		return merged[i].Profiles[0] < merged[j].Profiles[0]
+	})
+	return merged
+}
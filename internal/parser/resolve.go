@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Where a plugin's host/owner/repo came from, most trusted first. The
+// winning source is recorded in Plugin.ResolvedBy.
+const (
+	ResolvedLockfile = "lockfile" // the lockfile records the clone URL
+	ResolvedSpec     = "spec"     // a url or "owner/repo" in the config
+	ResolvedCheckout = "checkout" // the remote of the installed clone
+	ResolvedBuiltin  = "builtin"  // a plugin nvimgotrack knows about
+	ResolvedGuess    = "guess"    // derived from the plugin name alone
+)
+
+// DataDir returns Neovim's data directory for a profile:
+// $XDG_DATA_HOME/<appname>, falling back to ~/.local/share/<appname>.
+func DataDir(appName string) string {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, appName)
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", appName)
+}
+
+// checkoutDirs lists where the plugin manager may have cloned name: the
+// spec's dir, then the install paths of lazy.nvim, packer, mini.deps and
+// vim-plug under the profile's data dir.
+func checkoutDirs(name, appName, specDir string) []string {
+	var dirs []string
+	if specDir != "" {
+		dirs = append(dirs, expandHome(specDir))
+	}
+	data := DataDir(appName)
+	return append(dirs,
+		filepath.Join(data, "lazy", name),
+		filepath.Join(data, "site", "pack", "packer", "start", name),
+		filepath.Join(data, "site", "pack", "packer", "opt", name),
+		filepath.Join(data, "site", "pack", "deps", "start", name),
+		filepath.Join(data, "site", "pack", "deps", "opt", name),
+		filepath.Join(data, "plugged", name),
+	)
+}
+
+// checkoutLocation returns the repository of the first clone found in dirs.
+func checkoutLocation(dirs []string) (host, owner, repo string, ok bool) {
+	for _, dir := range dirs {
+		if url, found := remoteURL(dir); found {
+			if host, owner, repo, ok := ParseRemoteURL(url); ok {
+				return host, owner, repo, true
+			}
+		}
+	}
+	return "", "", "", false
+}
+
+// checkoutBranch returns the branch the first clone found in dirs follows:
+// the default branch of its origin remote, else the branch it has checked
+// out. It returns "" when neither is known.
+func checkoutBranch(dirs []string) string {
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		if head, err := os.ReadFile(filepath.Join(gitDir(dir), "refs", "remotes", "origin", "HEAD")); err == nil {
+			if ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/remotes/origin/"); ok {
+				return ref
+			}
+		}
+		branch, _ := gitHead(dir)
+		return branch
+	}
+	return ""
+}
+
+// remoteURL reads the URL of the "origin" remote, or of the first remote
+// when there is no origin, from the repository at dir.
+func remoteURL(dir string) (string, bool) {
+	config, err := os.ReadFile(filepath.Join(gitDir(dir), "config"))
+	if err != nil {
+		return "", false
+	}
+
+	var section, first string
+	for _, line := range strings.Split(string(config), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "url" || !strings.HasPrefix(section, "[remote ") {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if section == `[remote "origin"]` {
+			return value, true
+		}
+		if first == "" {
+			first = value
+		}
+	}
+	return first, first != ""
+}
+
+// gitDir returns the git directory of the work tree at dir, following a
+// "gitdir:" file as used by worktrees and submodules.
+func gitDir(dir string) string {
+	dotGit := filepath.Join(dir, ".git")
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return dotGit // a directory, or missing
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return dotGit
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return target
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
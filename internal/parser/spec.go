@@ -0,0 +1,406 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Spec is a lazy.nvim plugin spec as written in the user's Lua config.
+// Only literal values are captured; fields computed at runtime (e.g.
+// `enabled = vim.fn.has("mac") == 1`) are left unset.
+type Spec struct {
+	Short   string // positional [1], e.g. "folke/lazy.nvim"
+	URL     string
+	Dir     string
+	Name    string // explicit `name = ...` override
+	Branch  string
+	Tag     string
+	Version string // "" also covers `version = false`
+	Commit  string
+	Pin     bool
+	Enabled *bool // nil when absent or not a literal boolean
+	Dev     bool
+
+	// Dependency is true when the spec was only found inside another
+	// spec's `dependencies`.
+	Dependency bool
+
+	File string
+	Line int
+}
+
+// Diagnostic reports a spec that could not be mapped unambiguously.
+type Diagnostic struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	switch {
+	case d.File == "":
+		return d.Message
+	case d.Line == 0:
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+var shortSpecRe = regexp.MustCompile(`^[a-zA-Z0-9_\-\.]+/[a-zA-Z0-9_\-\.]+$`)
+
+// PluginName returns the name lazy.nvim uses for the plugin, which is also
+// its key in lazy-lock.json: the explicit name, otherwise the last path
+// component of dir, url or the short spec, without a ".git" suffix.
+func (s Spec) PluginName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	for _, src := range []string{s.Dir, s.URL, s.Short} {
+		if src == "" {
+			continue
+		}
+		src = strings.TrimSuffix(strings.TrimRight(src, "/"), ".git")
+		if i := strings.LastIndexAny(src, "/:"); i >= 0 {
+			src = src[i+1:]
+		}
+		return src
+	}
+	return ""
+}
+
+// Location returns where the plugin is cloned from. Specs that only have a
+// local dir have no location.
+func (s Spec) Location() (host, owner, repo string, ok bool) {
+	if s.URL != "" {
+		return ParseRemoteURL(s.URL)
+	}
+	if s.Short != "" {
+		owner, repo, _ := strings.Cut(s.Short, "/")
+		return DefaultHost, owner, repo, true
+	}
+	return "", "", "", false
+}
+
+// override renders the spec's location in the ScanConfig map format.
+func (s Spec) override() (string, bool) {
+	host, owner, repo, ok := s.Location()
+	if !ok {
+		return "", false
+	}
+	if host == DefaultHost {
+		return owner + "/" + repo, true
+	}
+	return host + "/" + owner + "/" + repo, true
+}
+
+// merge fills fields unset in s from other, mirroring how lazy.nvim merges
+// several fragments of the same plugin spec.
+func (s *Spec) merge(other Spec) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&s.Short, other.Short)
+	fill(&s.URL, other.URL)
+	fill(&s.Dir, other.Dir)
+	fill(&s.Branch, other.Branch)
+	fill(&s.Tag, other.Tag)
+	fill(&s.Version, other.Version)
+	fill(&s.Commit, other.Commit)
+	s.Pin = s.Pin || other.Pin
+	s.Dev = s.Dev || other.Dev
+	if s.Enabled == nil {
+		s.Enabled = other.Enabled
+	}
+	s.Dependency = s.Dependency && other.Dependency
+}
+
+// ScanSpecs parses every Lua file under root, plus vim-plug `Plug` lines
+// in Vimscript files, and returns the plugin specs found, keyed by plugin
+// name. When two specs with the same name
+// point at different repositories the first one (in path order) wins and
+// a diagnostic is recorded; files that fail to parse are reported too.
+func ScanSpecs(root string) (map[string]Spec, []Diagnostic, error) {
+	specs := make(map[string]Spec)
+	var diags []Diagnostic
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable files
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		isVim := strings.HasSuffix(path, ".vim")
+		if !isVim && !strings.HasSuffix(path, ".lua") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		var found []Spec
+		if isVim {
+			found = specsFromVimscript(string(content), rel)
+		} else {
+			var fileDiags []Diagnostic
+			found, fileDiags = specsFromSource(string(content), rel)
+			diags = append(diags, fileDiags...)
+		}
+		for _, s := range found {
+			diags = append(diags, addSpec(specs, s)...)
+		}
+		return nil
+	})
+
+	return specs, diags, err
+}
+
+// addSpec records s under its plugin name, merging it with an earlier
+// fragment for the same repository.
+func addSpec(specs map[string]Spec, s Spec) []Diagnostic {
+	name := s.PluginName()
+	if name == "" {
+		return nil
+	}
+	prev, ok := specs[name]
+	if !ok {
+		specs[name] = s
+		return nil
+	}
+
+	prevLoc, prevOK := prev.override()
+	loc, locOK := s.override()
+	if prevOK && locOK && !strings.EqualFold(prevLoc, loc) {
+		return []Diagnostic{{
+			File: s.File,
+			Line: s.Line,
+			Message: fmt.Sprintf("plugin %q maps to %s here but to %s at %s:%d; using the first",
+				name, loc, prevLoc, prev.File, prev.Line),
+		}}
+	}
+	prev.merge(s)
+	specs[name] = prev
+	return nil
+}
+
+// specCalls are the functions whose first string argument is a spec, as
+// in packer's `use "owner/repo"` or mini.deps' `MiniDeps.add("owner/repo")`.
+var specCalls = map[string]bool{"use": true, "add": true, "Plug": true}
+
+// specsFromSource extracts specs from one Lua file. Every table constructor
+// outside of a spec is a candidate spec list, as are string arguments of
+// specCalls.
+func specsFromSource(src, file string) ([]Spec, []Diagnostic) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, []Diagnostic{{File: file, Message: fmt.Sprintf("could not parse: %v", err)}}
+	}
+
+	var specs []Spec
+	var diags []Diagnostic
+	p := &luaParser{toks: toks}
+	for p.peek().kind != tokEOF {
+		t := p.peek()
+		switch {
+		case t.kind == tokSymbol && t.text == "{":
+			tbl, err := p.parseTableAt()
+			if err != nil {
+				return specs, append(diags, Diagnostic{File: file, Message: fmt.Sprintf("could not parse: %v", err)})
+			}
+			specs = collectSpecs(specs, luaValue{kind: luaTableValue, table: tbl, line: tbl.line}, file, false)
+		case t.kind == tokString && shortSpecRe.MatchString(t.text):
+			if p.specCallArg() {
+				specs = append(specs, Spec{Short: t.text, File: file, Line: t.line})
+			}
+			p.next()
+		default:
+			p.next()
+		}
+	}
+	return specs, diags
+}
+
+// specCallArg reports whether the current token is the first argument of
+// one of specCalls, written as `f "x"` or `f("x")`.
+func (p *luaParser) specCallArg() bool {
+	i := p.pos - 1
+	if i >= 0 && p.toks[i].kind == tokSymbol && p.toks[i].text == "(" {
+		i--
+	}
+	return i >= 0 && p.toks[i].kind == tokName && specCalls[p.toks[i].text]
+}
+
+var plugLineRe = regexp.MustCompile(`(?m)^\s*Plug\s+['"]([^'"]+)['"](.*)$`)
+var plugAsRe = regexp.MustCompile(`['"]as['"]\s*:\s*['"]([^'"]+)['"]`)
+
+// specsFromVimscript extracts vim-plug `Plug 'owner/repo', {'as': 'name'}`
+// declarations.
+func specsFromVimscript(src, file string) []Spec {
+	var specs []Spec
+	for _, m := range plugLineRe.FindAllStringSubmatchIndex(src, -1) {
+		source, rest := src[m[2]:m[3]], src[m[4]:m[5]]
+		s := Spec{File: file, Line: strings.Count(src[:m[0]], "\n") + 1}
+		switch {
+		case shortSpecRe.MatchString(source):
+			s.Short = source
+		case strings.Contains(source, ":"):
+			s.URL = source
+		default:
+			continue
+		}
+		if as := plugAsRe.FindStringSubmatch(rest); as != nil {
+			s.Name = as[1]
+		}
+		specs = append(specs, s)
+	}
+	return specs
+}
+
+// collectSpecs appends the specs described by v: a short "owner/repo"
+// string, a spec table, or a list of either. Like lazy.nvim, a table with
+// more than one positional item is a list. The `spec` field of a
+// lazy.setup() options table is followed as well.
+func collectSpecs(specs []Spec, v luaValue, file string, dependency bool) []Spec {
+	switch v.kind {
+	case luaString:
+		if shortSpecRe.MatchString(v.str) {
+			specs = append(specs, Spec{Short: v.str, File: file, Line: v.line, Dependency: dependency})
+		}
+		return specs
+	case luaTableValue:
+	default:
+		return specs
+	}
+
+	tbl := v.table
+	if len(tbl.array) > 1 {
+		for _, item := range tbl.array {
+			specs = collectSpecs(specs, item, file, dependency)
+		}
+		return specs
+	}
+	if s, ok := specFromTable(tbl, file, dependency); ok {
+		specs = append(specs, s)
+		if deps, ok := tbl.fields["dependencies"]; ok {
+			specs = collectSpecs(specs, deps, file, true)
+		}
+		return specs
+	}
+
+	for _, item := range tbl.array {
+		specs = collectSpecs(specs, item, file, dependency)
+	}
+	if inner, ok := tbl.fields["spec"]; ok {
+		specs = collectSpecs(specs, inner, file, dependency)
+	}
+	return specs
+}
+
+// specFromTable reads a spec table. A table is a spec when it has a short
+// source at [1], a url, a dir or a mini.deps source.
+func specFromTable(tbl *luaTable, file string, dependency bool) (Spec, bool) {
+	str := func(key string) string {
+		if v, ok := tbl.fields[key]; ok && v.kind == luaString {
+			return v.str
+		}
+		return ""
+	}
+	boolean := func(key string) (bool, bool) {
+		if v, ok := tbl.fields[key]; ok && v.kind == luaBool {
+			return v.b, true
+		}
+		return false, false
+	}
+
+	s := Spec{
+		URL:        str("url"),
+		Dir:        str("dir"),
+		Name:       str("name"),
+		Branch:     str("branch"),
+		Tag:        str("tag"),
+		Version:    str("version"),
+		Commit:     str("commit"),
+		File:       file,
+		Line:       tbl.line,
+		Dependency: dependency,
+	}
+	if len(tbl.array) > 0 && tbl.array[0].kind == luaString && shortSpecRe.MatchString(tbl.array[0].str) {
+		s.Short = tbl.array[0].str
+	}
+	// mini.deps specs name their repository `source`.
+	if source := str("source"); source != "" && s.Short == "" && s.URL == "" {
+		if shortSpecRe.MatchString(source) {
+			s.Short = source
+		} else {
+			s.URL = source
+		}
+	}
+	if s.Short == "" && s.URL == "" && s.Dir == "" {
+		return Spec{}, false
+	}
+	s.Pin, _ = boolean("pin")
+	s.Dev, _ = boolean("dev")
+	if enabled, ok := boolean("enabled"); ok {
+		s.Enabled = &enabled
+	}
+	return s, true
+}
+
+// ParseRemoteURL splits a clone URL into host, owner and repo. It accepts
+// https, ssh:// and scp-style (git@host:owner/repo) URLs; owner keeps any
+// subgroups, and a trailing ".git" is dropped.
+func ParseRemoteURL(raw string) (host, owner, repo string, ok bool) {
+	s := strings.TrimSpace(raw)
+	switch {
+	case strings.Contains(s, "://"):
+		s = s[strings.Index(s, "://")+3:]
+		if at := strings.Index(s, "@"); at >= 0 && at < strings.Index(s+"/", "/") {
+			s = s[at+1:]
+		}
+		var path string
+		host, path, _ = strings.Cut(s, "/")
+		if i := strings.Index(host, ":"); i >= 0 {
+			host = host[:i] // drop the port
+		}
+		s = path
+	case strings.Contains(s, ":"):
+		var path string
+		host, path, _ = strings.Cut(s, ":")
+		if at := strings.Index(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+		s = path
+	default:
+		return "", "", "", false
+	}
+
+	s = strings.TrimSuffix(strings.Trim(s, "/"), ".git")
+	i := strings.LastIndex(s, "/")
+	if host == "" || i <= 0 || i == len(s)-1 {
+		return "", "", "", false
+	}
+	return strings.ToLower(host), s[:i], s[i+1:], true
+}
+
+// sortedDiagnostics orders diagnostics by file and line for stable output.
+func sortedDiagnostics(diags []Diagnostic) []Diagnostic {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Line < diags[j].Line
+	})
+	return diags
+}
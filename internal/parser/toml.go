@@ -0,0 +1,274 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML decodes the subset of TOML used by rocks.toml: [tables] with
+// dotted and quoted keys, basic and literal strings, booleans, numbers,
+// arrays, which may span lines, and inline tables. Values are string, bool,
+// []any or map[string]any; numbers and dates are kept as their source text.
+func parseTOML(src string) (map[string]any, error) {
+	root := make(map[string]any)
+	current := root
+
+	p := &tomlParser{s: src, line: 1}
+	for ; p.i < len(p.s); p.nextLine() {
+		p.skipSpace()
+		if p.done() {
+			continue
+		}
+
+		if p.peek() == '[' {
+			if strings.HasPrefix(p.s[p.i:], "[[") {
+				return nil, p.errorf("arrays of tables are not supported")
+			}
+			p.i++
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(']') {
+				return nil, p.errorf("expected ]")
+			}
+			if current, err = tableAt(root, keys); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		} else {
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept('=') {
+				return nil, p.errorf("expected =")
+			}
+			val, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			tbl, err := tableAt(current, keys[:len(keys)-1])
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			tbl[keys[len(keys)-1]] = val
+		}
+
+		p.skipSpace()
+		if !p.done() {
+			return nil, p.errorf("unexpected %q", p.rest())
+		}
+	}
+	return root, nil
+}
+
+// tableAt returns the table at the dotted path keys, creating it as needed.
+func tableAt(root map[string]any, keys []string) (map[string]any, error) {
+	tbl := root
+	for _, k := range keys {
+		next, ok := tbl[k]
+		if !ok {
+			next = make(map[string]any)
+			tbl[k] = next
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not a table", k)
+		}
+		tbl = child
+	}
+	return tbl, nil
+}
+
+type tomlParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) peek() byte { return p.s[p.i] }
+
+// done reports whether only a comment or nothing is left on the line.
+func (p *tomlParser) done() bool {
+	return p.i >= len(p.s) || p.s[p.i] == '#' || p.s[p.i] == '\n'
+}
+
+// rest returns what is left of the current line.
+func (p *tomlParser) rest() string {
+	line, _, _ := strings.Cut(p.s[p.i:], "\n")
+	return line
+}
+
+// nextLine moves past the end of the current line.
+func (p *tomlParser) nextLine() {
+	if end := strings.IndexByte(p.s[p.i:], '\n'); end >= 0 {
+		p.i += end + 1
+		p.line++
+	} else {
+		p.i = len(p.s)
+	}
+}
+
+// skipBlank skips whitespace, comments and line breaks, as allowed between
+// the elements of an array.
+func (p *tomlParser) skipBlank() {
+	for p.skipSpace(); p.done() && p.i < len(p.s); p.skipSpace() {
+		p.nextLine()
+	}
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t' || p.s[p.i] == '\r') {
+		p.i++
+	}
+}
+
+func (p *tomlParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.i < len(p.s) && p.s[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// parseKey reads a possibly dotted key such as plugins."nvim-treesitter".
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		if p.done() {
+			return nil, p.errorf("expected key")
+		}
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			k, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		default:
+			start := p.i
+			for p.i < len(p.s) && (isAlnum(p.s[p.i]) || p.s[p.i] == '-') {
+				p.i++
+			}
+			if start == p.i {
+				return nil, p.errorf("expected key, found %q", p.rest())
+			}
+			keys = append(keys, p.s[start:p.i])
+		}
+		if !p.accept('.') {
+			return keys, nil
+		}
+	}
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	p.skipSpace()
+	if p.done() {
+		return nil, p.errorf("expected value")
+	}
+	switch c := p.peek(); c {
+	case '"', '\'':
+		if strings.HasPrefix(p.s[p.i:], `"""`) || strings.HasPrefix(p.s[p.i:], "'''") {
+			return nil, p.errorf("multi-line strings are not supported")
+		}
+		return p.parseString()
+	case '[':
+		p.i++
+		var arr []any
+		for p.skipBlank(); !p.accept(']'); p.skipBlank() {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+			p.skipBlank()
+			if !p.accept(',') {
+				p.skipBlank()
+				if !p.accept(']') {
+					return nil, p.errorf("expected , or ]")
+				}
+				break
+			}
+		}
+		return arr, nil
+	case '{':
+		p.i++
+		tbl := make(map[string]any)
+		for !p.accept('}') {
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept('=') {
+				return nil, p.errorf("expected =")
+			}
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			inner, err := tableAt(tbl, keys[:len(keys)-1])
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			inner[keys[len(keys)-1]] = v
+			if !p.accept(',') {
+				if !p.accept('}') {
+					return nil, p.errorf("expected , or }")
+				}
+				break
+			}
+		}
+		return tbl, nil
+	}
+
+	start := p.i
+	for p.i < len(p.s) && !strings.ContainsRune(",]}# \t\r\n", rune(p.s[p.i])) {
+		p.i++
+	}
+	switch raw := p.s[start:p.i]; raw {
+	case "":
+		return nil, p.errorf("expected value")
+	case "true", "false":
+		return raw == "true", nil
+	default:
+		return raw, nil
+	}
+}
+
+// parseString reads a basic ("...") or literal ('...') string.
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.peek()
+	if quote == '\'' {
+		end := strings.IndexAny(p.s[p.i+1:], "'\n")
+		if end < 0 || p.s[p.i+1+end] == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		s := p.s[p.i+1 : p.i+1+end]
+		p.i += end + 2
+		return s, nil
+	}
+
+	for j := p.i + 1; j < len(p.s); j++ {
+		switch p.s[j] {
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '\\':
+			j++
+		case '"':
+			s, err := strconv.Unquote(p.s[p.i : j+1])
+			if err != nil {
+				return "", p.errorf("invalid string %s", p.s[p.i:j+1])
+			}
+			p.i = j + 1
+			return s, nil
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
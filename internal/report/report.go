@@ -0,0 +1,70 @@
+// Package report defines the versioned JSON document written by
+// nvimgotrack for a whole run, and reads it back.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Giankrp/nvimgotrack/internal/detector"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+// SchemaVersion is bumped whenever a field is renamed or removed, or its
+// meaning changes. Adding optional fields does not bump it.
+const SchemaVersion = 1
+
+// Document is the top-level JSON object for one run.
+type Document struct {
+	SchemaVersion int                     `json:"schema_version"`
+	GeneratedAt   time.Time               `json:"generated_at"`
+	Lockfile      string                  `json:"lockfile,omitempty"`
+	Plugins       []detector.PluginReport `json:"plugins"`
+
+	// Profiles lists the NVIM_APPNAME configs merged into Plugins by an
+	// -all-profiles run; Lockfile is empty then.
+	Profiles []parser.Profile `json:"profiles,omitempty"`
+
+	// Diagnostics lists config specs that could not be mapped to a
+	// repository unambiguously.
+	Diagnostics []parser.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// New wraps reports in a Document stamped with the current schema version.
+func New(lockfile string, reports []detector.PluginReport) Document {
+	return Document{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Lockfile:      lockfile,
+		Plugins:       reports,
+	}
+}
+
+// Write encodes doc as indented JSON.
+func Write(w io.Writer, doc Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	return nil
+}
+
+// Read decodes a Document previously produced by Write. Documents with a
+// newer schema version than this build understands are rejected.
+func Read(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding report: %w", err)
+	}
+	if doc.SchemaVersion == 0 {
+		return nil, fmt.Errorf("decoding report: missing schema_version")
+	}
+	if doc.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("report schema version %d is newer than supported version %d",
+			doc.SchemaVersion, SchemaVersion)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Giankrp/nvimgotrack/internal/detector"
+	"github.com/Giankrp/nvimgotrack/internal/parser"
+)
+
+func TestRoundTrip(t *testing.T) {
+	reports := []detector.PluginReport{
+		{
+			Plugin:   parser.Plugin{Name: "telescope.nvim", Branch: "master", Commit: "a1b2c3", Owner: "nvim-telescope", Repo: "telescope.nvim"},
+			Severity: detector.SeverityBreaking,
+			BehindBy: 12,
+			BreakingMsgs: []detector.CommitMessage{
+				{SHA: "deadbeef", Message: "feat!: drop nvim 0.9", URL: "https://github.com/nvim-telescope/telescope.nvim/commit/deadbeef"},
+			},
+			Releases: []detector.ReleaseInfo{
+				{Tag: "v0.2.0", Name: "0.2.0", Severity: detector.SeverityFeature},
+			},
+			CompareURL: "https://github.com/nvim-telescope/telescope.nvim/compare/a1b2c3...master",
+		},
+		{
+			Plugin: parser.Plugin{Name: "gone.nvim", Owner: "gone", Repo: "gone.nvim"},
+			Error:  "compare failed: not found",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, New("/tmp/lazy-lock.json", reports)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"severity": "breaking"`) {
+		t.Errorf("expected machine severity name in output:\n%s", buf.String())
+	}
+
+	doc, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if doc.SchemaVersion != SchemaVersion {
+		t.Errorf("schema version: got %d, want %d", doc.SchemaVersion, SchemaVersion)
+	}
+	if !reflect.DeepEqual(doc.Plugins, reports) {
+		t.Errorf("plugins did not round-trip:\ngot  %+v\nwant %+v", doc.Plugins, reports)
+	}
+}
+
+func TestReadRejectsNewerSchema(t *testing.T) {
+	_, err := Read(strings.NewReader(`{"schema_version": 99, "plugins": []}`))
+	if err == nil {
+		t.Error("expected error for newer schema version")
+	}
+}
+
+func TestReadRejectsUnknownSeverity(t *testing.T) {
+	_, err := Read(strings.NewReader(`{"schema_version": 1, "plugins": [{"severity": "catastrophic"}]}`))
+	if err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
@@ -0,0 +1,194 @@
+// Package semver parses version tags and lazy.nvim-style version ranges
+// such as "^2", "~1.4", ">=0.9.0" or "1.2 - 1.5".
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version. Missing minor and patch numbers
+// are zero, so "v2" is 2.0.0.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string
+	Build               string
+}
+
+var versionRe = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.\-]+))?(?:\+([0-9A-Za-z.\-]+))?$`)
+
+// Parse parses a version or tag name like "v1.2.3-rc1". Tags that are not
+// versions ("nightly", "stable") return false.
+func Parse(s string) (Version, bool) {
+	m := versionRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Version{}, false
+	}
+	num := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	return Version{
+		Major:      num(m[1]),
+		Minor:      num(m[2]),
+		Patch:      num(m[3]),
+		Prerelease: m[4],
+		Build:      m[5],
+	}, true
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than
+// o. A prerelease sorts before its release; build metadata is ignored.
+func (v Version) Compare(o Version) int {
+	for _, d := range [...]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+	return sign(strings.Compare(v.Prerelease, o.Prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Range is a half-open interval [From, To). A nil To has no upper bound.
+type Range struct {
+	From Version
+	To   *Version
+}
+
+// ParseRange parses a version range with lazy.nvim semantics:
+//
+//	"*" or ""     any release
+//	"1.2.3"       exactly 1.2.3 (also "=1.2.3")
+//	"1.2", "1.x"  like "~1.2"
+//	"~1.2.3"      >=1.2.3 <1.3.0
+//	"^1.2.3"      >=1.2.3 <2.0.0, and "^0.2.3" is <0.3.0
+//	">1.2.3"      >=1.2.4
+//	">=1.2.3"     no upper bound
+//	"1.2 - 1.5"   >=1.2.0 <1.6.0, but "1.2 - 1.5.3" is <1.5.3
+func ParseRange(spec string) (Range, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "*" || spec == "" {
+		return Range{}, nil
+	}
+
+	if a, b, ok := strings.Cut(spec, " - "); ok {
+		from, err := ParseRange(a)
+		if err != nil {
+			return Range{}, err
+		}
+		to, err := ParseRange(b)
+		if err != nil {
+			return Range{}, err
+		}
+		r := Range{From: from.From, To: to.To}
+		if strings.Count(strings.TrimSpace(b), ".") == 2 {
+			// lazy.nvim treats a full upper version as exclusive.
+			upper := to.From
+			r.To = &upper
+		}
+		return r, nil
+	}
+
+	s := strings.ToLower(spec)
+	version := strings.TrimLeft(s, "^=<>~")
+	mods := s[:len(s)-len(version)]
+	version = strings.NewReplacer(".*", "", ".x", "").Replace(version)
+	parts := len(strings.Split(strings.SplitN(version, "-", 2)[0], "."))
+	if parts < 3 && mods == "" {
+		mods = "~"
+	}
+
+	v, ok := Parse(version)
+	if !ok {
+		return Range{}, fmt.Errorf("invalid version range %q", spec)
+	}
+	from, to := v, v
+	r := Range{From: from, To: &to}
+	switch mods {
+	case "", "=":
+		to.Patch++
+	case "<":
+		r.From = Version{}
+	case "<=":
+		r.From = Version{}
+		to.Patch++
+	case ">":
+		r.From.Patch++
+		r.To = nil
+	case ">=":
+		r.To = nil
+	case "~":
+		if parts >= 2 {
+			to = Version{Major: v.Major, Minor: v.Minor + 1}
+		} else {
+			to = Version{Major: v.Major + 1}
+		}
+	case "^":
+		switch {
+		case v.Major != 0:
+			to = Version{Major: v.Major + 1}
+		case v.Minor != 0:
+			to = Version{Minor: v.Minor + 1}
+		default:
+			to = Version{Patch: v.Patch + 1}
+		}
+	default:
+		return Range{}, fmt.Errorf("invalid version range %q", spec)
+	}
+	return r, nil
+}
+
+// Matches reports whether v falls in r. As in lazy.nvim, prereleases only
+// match a range whose lower bound is the same prerelease.
+func (r Range) Matches(v Version) bool {
+	if v.Prerelease != r.From.Prerelease {
+		return false
+	}
+	return v.Compare(r.From) >= 0 && (r.To == nil || v.Compare(*r.To) < 0)
+}
+
+// Latest returns the newest tag in tags that parses as a version inside r.
+func (r Range) Latest(tags []string) (string, Version, bool) {
+	var best string
+	var bestV Version
+	for _, tag := range tags {
+		v, ok := Parse(tag)
+		if !ok || !r.Matches(v) {
+			continue
+		}
+		if best == "" || v.Compare(bestV) > 0 {
+			best, bestV = tag, v
+		}
+	}
+	return best, bestV, best != ""
+}
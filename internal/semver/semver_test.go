@@ -0,0 +1,98 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"v1.2.3", "1.2.3", true},
+		{"2", "2.0.0", true},
+		{"v0.10", "0.10.0", true},
+		{"1.0.0-rc.1+build5", "1.0.0-rc.1+build5", true},
+		{"nightly", "", false},
+		{"v1.2.3.4", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := Parse(tt.in)
+		if ok != tt.ok || (ok && v.String() != tt.want) {
+			t.Errorf("Parse(%q) = %v, %v; want %s, %v", tt.in, v, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0+a", "1.0.0+b", 0},
+	}
+	for _, tt := range tests {
+		a, _ := Parse(tt.a)
+		b, _ := Parse(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRangeMatches(t *testing.T) {
+	tests := []struct {
+		spec string
+		yes  []string
+		no   []string
+	}{
+		{"*", []string{"0.0.1", "9.9.9"}, []string{"1.0.0-rc1"}},
+		{"^2", []string{"2.0.0", "2.9.1"}, []string{"1.9.9", "3.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.4", []string{"1.4.0", "1.4.7"}, []string{"1.5.0"}},
+		{"1.4", []string{"1.4.2"}, []string{"1.5.0"}},
+		{"1.x", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"1.4.0", []string{"1.4.0"}, []string{"1.4.1"}},
+		{"=1.4.0", []string{"1.4.0"}, []string{"1.3.9"}},
+		{">1.4.0", []string{"1.4.1", "5.0.0"}, []string{"1.4.0"}},
+		{">=1.4.0", []string{"1.4.0", "5.0.0"}, []string{"1.3.9"}},
+		{"1.2 - 1.5", []string{"1.2.0", "1.5.9"}, []string{"1.6.0", "1.1.9"}},
+		{"1.2 - 1.5.3", []string{"1.5.2"}, []string{"1.5.3"}},
+	}
+	for _, tt := range tests {
+		r, err := ParseRange(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseRange(%q): %v", tt.spec, err)
+		}
+		for _, s := range tt.yes {
+			if v, _ := Parse(s); !r.Matches(v) {
+				t.Errorf("%q should match %s", tt.spec, s)
+			}
+		}
+		for _, s := range tt.no {
+			if v, _ := Parse(s); r.Matches(v) {
+				t.Errorf("%q should not match %s", tt.spec, s)
+			}
+		}
+	}
+
+	if _, err := ParseRange("latest"); err == nil {
+		t.Error("expected error for invalid range")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	r, _ := ParseRange("^1")
+	tag, v, ok := r.Latest([]string{"v0.9.0", "v1.2.0", "nightly", "v1.10.0", "v2.0.0", "v1.11.0-rc1"})
+	if !ok || tag != "v1.10.0" || v.Minor != 10 {
+		t.Errorf("Latest = %q %v %v, want v1.10.0", tag, v, ok)
+	}
+	if _, _, ok := r.Latest([]string{"v2.0.0"}); ok {
+		t.Error("expected no match")
+	}
+}
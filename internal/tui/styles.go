@@ -54,6 +54,11 @@ var (
 	okStyle = lipgloss.NewStyle().
 		Foreground(colorOK)
 
+	// movedStyle marks plugins whose repository was renamed or transferred
+	movedStyle = lipgloss.NewStyle().
+			Foreground(colorAccent).
+			Bold(true)
+
 	// Detail view
 	detailTitleStyle = lipgloss.NewStyle().
 				Bold(true).
@@ -85,6 +90,16 @@ var (
 				Foreground(colorDim).
 				PaddingLeft(4)
 
+	// matchStyle highlights the words a finding matched.
+	matchStyle = lipgloss.NewStyle().
+			Foreground(colorWhite).
+			Bold(true).
+			Underline(true)
+
+	commitKindStyle = lipgloss.NewStyle().
+			Foreground(colorMuted).
+			PaddingLeft(6)
+
 	// Help
 	helpStyle = lipgloss.NewStyle().
 			Foreground(colorMuted).
@@ -1,16 +1,22 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/Giankrp/nvimgotrack/internal/detector"
+	"github.com/Giankrp/nvimgotrack/internal/forge"
 	"github.com/Giankrp/nvimgotrack/internal/github"
 	"github.com/Giankrp/nvimgotrack/internal/parser"
+	"github.com/Giankrp/nvimgotrack/internal/semver"
 )
 
 // view is the current screen.
@@ -37,64 +43,210 @@ type Model struct {
 	plugins  []parser.Plugin
 	reports  []detector.PluginReport
 	filtered []int // indices into reports
-	client   *github.Client
+	forges   *forge.Registry
+	profiles []string // NVIM_APPNAME profiles, when several were merged
+	warnings []parser.Diagnostic
+	opts     Options
 
 	// UI state
-	cursor      int
-	view        view
-	filter      filter
-	width       int
-	height      int
-	scrollTop   int // for detail view scrolling
-	detailLines int
+	cursor       int
+	view         view
+	filter       filter
+	profile      int // 0 shows every profile, i shows profiles[i-1]
+	width        int
+	height       int
+	scrollTop    int // for detail view scrolling
+	detailLines  int
+	showWarnings bool
 
 	// Loading
-	loading     bool
-	loadingIdx  int
-	loadingName string
-	spinner     spinner.Model
-	done        bool
+	ctx        context.Context // parent of every run
+	cancel     context.CancelFunc
+	run        int // incremented by each re-run; older events are dropped
+	loading    bool
+	loadingIdx int // number of finished plugins
+	finished   []bool
+	inFlight   map[int]bool
+	events     <-chan detector.Event
+	pauses     <-chan github.Pause
+	pause      github.Pause // latest rate limit wait
+	spinner    spinner.Model
+	done       bool
+}
+
+type pluginStarted struct {
+	run   int
+	index int
 }
 
 type pluginAnalyzed struct {
+	run    int
 	index  int
 	report detector.PluginReport
 }
 
-type allDone struct{}
+type allDone struct {
+	run int
+}
+
+// runStarted carries the events of a run that Init or a re-run started.
+type runStarted struct {
+	run    int
+	events <-chan detector.Event
+	cancel context.CancelFunc
+}
 
-// NewModel creates a new TUI model.
-func NewModel(plugins []parser.Plugin, client *github.Client) Model {
+type rateLimited struct {
+	pause github.Pause
+}
+
+// Options configures how the TUI runs the analysis.
+type Options struct {
+	// Workers is the number of plugins analyzed concurrently.
+	Workers int
+	// RunTimeout and PluginTimeout, if positive, bound each run and each
+	// plugin within it.
+	RunTimeout    time.Duration
+	PluginTimeout time.Duration
+	// Rules adds user detection rules to the built-in ones.
+	Rules *detector.Rules
+	// Local, if set, dates locked commits that a force-push removed
+	// upstream.
+	Local detector.CommitSource
+	// Pauses delivers rate limit waits, which are shown while loading.
+	Pauses <-chan github.Pause
+	// Warnings are the problems found while parsing the lockfile and
+	// specs, listed above the plugins.
+	Warnings []parser.Diagnostic
+}
+
+// NewModel creates a new TUI model that analyzes plugins, routing each one
+// to the forge for its host. The analysis starts from Init. Canceling ctx
+// stops it; so does quitting, and a re-run abandons the one before it.
+func NewModel(ctx context.Context, plugins []parser.Plugin, forges *forge.Registry, opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
 
-	return Model{
-		plugins: plugins,
-		reports: make([]detector.PluginReport, len(plugins)),
-		client:  client,
-		loading: true,
-		spinner: s,
-		filter:  filterAll,
+	var profiles []string
+	seen := make(map[string]bool)
+	for _, p := range plugins {
+		for _, prof := range p.Profiles {
+			if !seen[prof] {
+				seen[prof] = true
+				profiles = append(profiles, prof)
+			}
+		}
+	}
+	sort.Strings(profiles)
+
+	m := Model{
+		plugins:  plugins,
+		profiles: profiles,
+		warnings: opts.Warnings,
+		forges:   forges,
+		opts:     opts,
+		ctx:      ctx,
+		spinner:  s,
+		filter:   filterAll,
+		pauses:   opts.Pauses,
+	}
+	m.resetRun()
+	return m
+}
+
+// resetRun abandons the current analysis, if any, and clears the results
+// for the next one, which startRun then begins.
+func (m *Model) resetRun() {
+	m.stopRun()
+	m.run++
+
+	m.reports = make([]detector.PluginReport, len(m.plugins))
+	m.finished = make([]bool, len(m.plugins))
+	m.inFlight = make(map[int]bool)
+	m.filtered = nil
+	m.loadingIdx = 0
+	m.loading = true
+	m.done = false
+	m.view = viewList
+	m.cursor = 0
+}
+
+// stopRun cancels the current analysis, if any. Events it still sends are
+// drained and ignored.
+func (m *Model) stopRun() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	drain(m.events)
+	m.cancel, m.events = nil, nil
+}
+
+// drain discards what is left of a canceled run's events.
+func drain(events <-chan detector.Event) {
+	go func() {
+		for range events {
+		}
+	}()
+}
+
+// startRun returns a command that starts the analysis for the current run.
+func (m Model) startRun() tea.Cmd {
+	ctx, forges, plugins, opts, run := m.ctx, m.forges, m.plugins, m.opts, m.run
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(ctx)
+		if opts.RunTimeout > 0 {
+			var stop context.CancelFunc
+			ctx, stop = context.WithTimeout(ctx, opts.RunTimeout)
+			cancelRun := cancel
+			cancel = func() { stop(); cancelRun() }
+		}
+		events := detector.AnalyzeAllWithOptions(ctx, forges, plugins, detector.Options{
+			Workers:       opts.Workers,
+			PluginTimeout: opts.PluginTimeout,
+			Rules:         opts.Rules,
+			Local:         opts.Local,
+		})
+		return runStarted{run: run, events: events, cancel: cancel}
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
-		m.analyzeNext(0),
+		m.startRun(),
+		m.waitForPause(),
 	)
 }
 
-func (m Model) analyzeNext(i int) tea.Cmd {
-	if i >= len(m.plugins) {
-		return func() tea.Msg { return allDone{} }
+// waitForPause turns the next rate limit wait into a message.
+func (m Model) waitForPause() tea.Cmd {
+	pauses := m.pauses
+	if pauses == nil {
+		return nil
 	}
-	plugin := m.plugins[i]
-	client := m.client
 	return func() tea.Msg {
-		report := detector.Analyze(client, plugin)
-		return pluginAnalyzed{index: i, report: report}
+		p, ok := <-pauses
+		if !ok {
+			return nil
+		}
+		return rateLimited{pause: p}
+	}
+}
+
+// waitForEvent turns the next event from the worker pool into a message.
+func (m Model) waitForEvent() tea.Cmd {
+	events, run := m.events, m.run
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return allDone{run: run}
+		}
+		if ev.Started {
+			return pluginStarted{run: run, index: ev.Index}
+		}
+		return pluginAnalyzed{run: run, index: ev.Index, report: ev.Report}
 	}
 }
 
@@ -115,16 +267,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case runStarted:
+		if msg.run != m.run {
+			msg.cancel()
+			drain(msg.events)
+			return m, nil
+		}
+		m.events, m.cancel = msg.events, msg.cancel
+		return m, m.waitForEvent()
+
+	case pluginStarted:
+		if msg.run != m.run {
+			return m, nil
+		}
+		m.inFlight[msg.index] = true
+		return m, m.waitForEvent()
+
 	case pluginAnalyzed:
-		m.reports[msg.index] = msg.report
-		m.loadingIdx = msg.index + 1
-		if msg.index+1 < len(m.plugins) {
-			m.loadingName = m.plugins[msg.index+1].Name
+		if msg.run != m.run {
+			return m, nil
 		}
+		delete(m.inFlight, msg.index)
+		m.reports[msg.index] = msg.report
+		m.finished[msg.index] = true
+		m.loadingIdx++
 		m.applyFilter()
-		return m, m.analyzeNext(msg.index + 1)
+		return m, m.waitForEvent()
+
+	case rateLimited:
+		m.pause = msg.pause
+		return m, m.waitForPause()
 
 	case allDone:
+		if msg.run != m.run {
+			return m, nil
+		}
+		m.cancel()
+		m.cancel, m.events = nil, nil
 		m.loading = false
 		m.done = true
 		detector.SortReports(m.reports)
@@ -147,6 +326,7 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.scrollTop = 0
 			return m, nil
 		}
+		m.stopRun()
 		return m, tea.Quit
 
 	case "esc":
@@ -214,6 +394,31 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.applyFilter()
 		m.cursor = 0
 		return m, nil
+
+	case "r":
+		if m.view == viewList {
+			wasLoading := m.loading
+			m.resetRun()
+			if wasLoading {
+				return m, m.startRun()
+			}
+			return m, tea.Batch(m.spinner.Tick, m.startRun())
+		}
+		return m, nil
+
+	case "p":
+		if m.view == viewList && len(m.profiles) > 0 {
+			m.profile = (m.profile + 1) % (len(m.profiles) + 1)
+			m.applyFilter()
+			m.cursor = 0
+		}
+		return m, nil
+
+	case "w":
+		if m.view == viewList && len(m.warnings) > 0 {
+			m.showWarnings = !m.showWarnings
+		}
+		return m, nil
 	}
 
 	return m, nil
@@ -223,6 +428,9 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m *Model) applyFilter() {
 	m.filtered = m.filtered[:0]
 	for i, r := range m.reports {
+		if m.profile > 0 && !slices.Contains(r.Plugin.Profiles, m.profiles[m.profile-1]) {
+			continue
+		}
 		switch m.filter {
 		case filterAll:
 			m.filtered = append(m.filtered, i)
@@ -245,6 +453,34 @@ func (m *Model) applyFilter() {
 	}
 }
 
+// profileLabel names the selected profile tab.
+func (m Model) profileLabel() string {
+	if m.profile == 0 {
+		return "All profiles"
+	}
+	return m.profiles[m.profile-1]
+}
+
+// profilePins lists, for every profile locking the same repository as p,
+// the commit it is pinned at.
+func (m Model) profilePins(p parser.Plugin) string {
+	if len(p.Profiles) == 0 {
+		return ""
+	}
+	var pins []string
+	for _, r := range m.reports {
+		other := r.Plugin
+		if !strings.EqualFold(other.Host+"/"+other.Owner+"/"+other.Repo, p.Host+"/"+p.Owner+"/"+p.Repo) {
+			continue
+		}
+		for _, prof := range other.Profiles {
+			pins = append(pins, fmt.Sprintf("%s @ %s", prof, other.Locked()[:min(10, len(other.Locked()))]))
+		}
+	}
+	sort.Strings(pins)
+	return strings.Join(pins, ", ")
+}
+
 // View renders the UI.
 func (m Model) View() string {
 	if m.width == 0 {
@@ -272,17 +508,26 @@ func (m Model) View() string {
 func (m Model) viewLoading() string {
 	var b strings.Builder
 	progress := fmt.Sprintf("%d/%d", m.loadingIdx, len(m.plugins))
-	name := m.loadingName
-	if m.loadingIdx < len(m.plugins) {
-		name = m.plugins[m.loadingIdx].Name
-	}
 	b.WriteString(fmt.Sprintf("\n  %s Analyzing plugins... %s\n", m.spinner.View(), progress))
-	b.WriteString(fmt.Sprintf("    → %s\n", name))
+	if left := time.Until(m.pause.Until); left > 0 {
+		b.WriteString(deprecStyle.Render(fmt.Sprintf("  ⏸ %s hit — paused until %s (%s left)",
+			m.pause.Reason, m.pause.Until.Local().Format(time.TimeOnly), left.Round(time.Second))))
+		b.WriteString("\n")
+	}
+
+	// Show every plugin a worker is currently on, in lockfile order
+	for i := range m.plugins {
+		if m.inFlight[i] {
+			b.WriteString(fmt.Sprintf("    → %s\n", m.plugins[i].Name))
+		}
+	}
 
 	// Show already-completed plugins
 	b.WriteString("\n")
-	for i := 0; i < m.loadingIdx && i < len(m.reports); i++ {
-		r := m.reports[i]
+	for i, r := range m.reports {
+		if !m.finished[i] {
+			continue
+		}
 		icon := r.Severity.Icon()
 		b.WriteString(fmt.Sprintf("  %s %s", icon, r.Plugin.Name))
 		if r.Error != "" {
@@ -291,6 +536,9 @@ func (m Model) viewLoading() string {
 		b.WriteString("\n")
 	}
 
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("  r restart  •  q quit"))
+
 	return b.String()
 }
 
@@ -331,7 +579,17 @@ func (m Model) viewListView() string {
 			tabs = append(tabs, filterInactiveStyle.Render(f))
 		}
 	}
-	b.WriteString("  " + lipgloss.JoinHorizontal(lipgloss.Top, tabs...) + "\n\n")
+	b.WriteString("  " + lipgloss.JoinHorizontal(lipgloss.Top, tabs...) + "\n")
+	if len(m.profiles) > 0 {
+		profileTabs := []string{filterInactiveStyle.Render("All profiles")}
+		for _, prof := range m.profiles {
+			profileTabs = append(profileTabs, filterInactiveStyle.Render(prof))
+		}
+		profileTabs[m.profile] = filterActiveStyle.Render(m.profileLabel())
+		b.WriteString("  " + lipgloss.JoinHorizontal(lipgloss.Top, profileTabs...) + "\n")
+	}
+	warningLines := m.viewWarnings(&b)
+	b.WriteString("\n")
 
 	header := fmt.Sprintf("  %-3s %-32s %-12s %-10s %s",
 		"", "Plugin", "Commit", "Behind", "Status")
@@ -340,7 +598,10 @@ func (m Model) viewListView() string {
 	b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render("  " + strings.Repeat("─", min(m.width-4, 90))))
 	b.WriteString("\n")
 
-	listHeight := m.height - 9
+	listHeight := m.height - 9 - warningLines
+	if len(m.profiles) > 0 {
+		listHeight-- // profile tabs
+	}
 	if listHeight < 1 {
 		listHeight = 10
 	}
@@ -363,7 +624,7 @@ func (m Model) viewListView() string {
 			name = name[:27] + "..."
 		}
 
-		commit := r.Plugin.Commit
+		commit := r.Plugin.Locked()
 		if len(commit) > 10 {
 			commit = commit[:10]
 		}
@@ -372,16 +633,36 @@ func (m Model) viewListView() string {
 		if r.BehindBy > 0 {
 			behindStr = fmt.Sprintf("+%d", r.BehindBy)
 		}
+		if r.Partial {
+			behindStr += "…"
+		}
 
 		statusStr := ""
-		if r.Error != "" {
+		switch {
+		case r.Error != "":
 			statusStr = errorStyle.Render("error")
-		} else {
+		case r.MovedTo != "":
+			statusStr = movedStyle.Render("moved") + " " + severityLabel(r.Severity)
+		default:
 			statusStr = severityLabel(r.Severity)
 		}
+		if r.Health.Status != detector.HealthOK {
+			statusStr += " " + healthLabel(r.Health.Status)
+		}
+		switch {
+		case r.Rewritten:
+			statusStr += " " + errorStyle.Render("rewritten")
+		case r.Health.BranchMissing:
+			statusStr += " " + movedStyle.Render("branch gone")
+		case r.Diverged():
+			statusStr += " " + movedStyle.Render("diverged")
+		}
 
 		line := fmt.Sprintf("  %s %-32s %-12s %-10s %s",
 			icon, name, commit, behindStr, statusStr)
+		if m.profile == 0 && len(r.Plugin.Profiles) > 0 {
+			line += "  " + strings.Join(r.Plugin.Profiles, ",")
+		}
 
 		if idx == m.cursor {
 			b.WriteString(selectedItemStyle.Width(m.width).Render(line))
@@ -393,12 +674,41 @@ func (m Model) viewListView() string {
 
 	// Help bar
 	b.WriteString("\n")
-	help := "  j/k navigate  •  enter detail  •  tab filter  •  q quit"
-	b.WriteString(helpStyle.Render(help))
+	keys := []string{"j/k navigate", "enter detail", "tab filter"}
+	if len(m.profiles) > 0 {
+		keys = append(keys, "p profile")
+	}
+	if len(m.warnings) > 0 {
+		keys = append(keys, "w warnings")
+	}
+	keys = append(keys, "r re-run", "q quit")
+	b.WriteString(helpStyle.Render("  " + strings.Join(keys, "  •  ")))
 
 	return b.String()
 }
 
+// viewWarnings writes the parse warnings line, and the warnings themselves
+// when toggled on, returning how many lines it wrote.
+func (m Model) viewWarnings(b *strings.Builder) int {
+	if len(m.warnings) == 0 {
+		return 0
+	}
+	toggle := "w to show"
+	if m.showWarnings {
+		toggle = "w to hide"
+	}
+	b.WriteString(deprecStyle.Render(fmt.Sprintf("  ⚠ %d config warnings (%s)", len(m.warnings), toggle)))
+	b.WriteString("\n")
+	if !m.showWarnings {
+		return 1
+	}
+	for _, d := range m.warnings {
+		b.WriteString(helpStyle.Render("    " + truncate(d.String(), max(m.width-6, 20))))
+		b.WriteString("\n")
+	}
+	return 1 + len(m.warnings)
+}
+
 // viewDetailView renders the detail screen for the selected plugin.
 func (m Model) viewDetailView() string {
 	if len(m.filtered) == 0 {
@@ -422,11 +732,41 @@ func (m Model) viewDetailView() string {
 		b.WriteString("\n")
 	}
 
-	addField("Repository:", fmt.Sprintf("%s/%s", r.Plugin.Owner, r.Plugin.Repo))
+	repoName := fmt.Sprintf("%s/%s", r.Plugin.Owner, r.Plugin.Repo)
+	if r.Plugin.Host != "" && r.Plugin.Host != parser.DefaultHost {
+		repoName = r.Plugin.Host + "/" + repoName
+	}
+	if r.Plugin.ResolvedBy != "" {
+		repoName += "  (from " + r.Plugin.ResolvedBy + ")"
+	}
+	addField("Repository:", repoName)
+	if r.MovedTo != "" {
+		addField("Moved to:", movedStyle.Render(r.MovedTo))
+		addField("Suggested fix:", r.SpecFix)
+	}
 	addField("Branch:", r.Plugin.Branch)
-	addField("Current Commit:", r.Plugin.Commit[:min(12, len(r.Plugin.Commit))])
-	addField("Behind by:", fmt.Sprintf("%d commits", r.BehindBy))
+	if note := r.BranchNote(); note != "" {
+		addField("Branch status:", movedStyle.Render(note))
+	}
+	if note := r.HistoryNote(); note != "" {
+		addField("History:", errorStyle.Render(note))
+	}
+	if pins := m.profilePins(r.Plugin); pins != "" {
+		addField("Profiles:", pins)
+	}
+	addField("Current Commit:", r.Plugin.Locked()[:min(12, len(r.Plugin.Locked()))])
+	behind := fmt.Sprintf("%d commits", r.BehindBy)
+	if r.Partial {
+		behind += deprecStyle.Render("  (partial: raise -max-commits to scan them all)")
+	}
+	addField("Behind by:", behind)
+	if r.Target != "" {
+		addField("Update target:", fmt.Sprintf("%s (+%d commits beyond)", r.Target[:min(12, len(r.Target))], r.BeyondBy))
+	}
 	addField("Severity:", r.Severity.String())
+	if health := healthDetail(r.Health); health != "" {
+		addField("Health:", health)
+	}
 
 	if r.CompareURL != "" {
 		addField("Compare URL:", r.CompareURL)
@@ -444,8 +784,9 @@ func (m Model) viewDetailView() string {
 		b.WriteString("  " + detailSectionStyle.Render("🔴 Breaking Changes"))
 		b.WriteString("\n")
 		for _, msg := range r.BreakingMsgs {
-			b.WriteString(breakingStyle.Render("    • " + truncate(msg, m.width-8)))
+			b.WriteString(breakingStyle.Render("    • " + truncate(msg.Message, m.width-8)))
 			b.WriteString("\n")
+			b.WriteString(m.commitDetail(msg))
 		}
 	}
 
@@ -455,12 +796,39 @@ func (m Model) viewDetailView() string {
 		b.WriteString("  " + detailSectionStyle.Render("🟡 Deprecation Warnings"))
 		b.WriteString("\n")
 		for _, msg := range r.DeprecMsgs {
-			b.WriteString(deprecStyle.Render("    • " + truncate(msg, m.width-8)))
+			b.WriteString(deprecStyle.Render("    • " + truncate(msg.Message, m.width-8)))
 			b.WriteString("\n")
+			b.WriteString(m.commitDetail(msg))
 		}
 	}
 
-	// Releases
+	// Why the plugin got its severity
+	if len(r.Findings) > 0 {
+		b.WriteString("\n")
+		b.WriteString("  " + detailSectionStyle.Render("🔎 Findings"))
+		b.WriteString("\n")
+		for _, f := range r.Findings {
+			b.WriteString(fmt.Sprintf("    %s %s %s · %s\n", f.Severity.Icon(), f.Source,
+				releaseTagStyle.Render(shortRef(f.Ref)), f.Rule))
+			b.WriteString(bodySnippetStyle.Render(highlightMatch(f, m.width-10)))
+			b.WriteString("\n")
+		}
+	}
+
+	// Breaking changes the version constraint keeps out
+	if len(r.BeyondBreaking) > 0 {
+		b.WriteString("\n")
+		b.WriteString("  " + detailSectionStyle.Render("⏭  Breaking Beyond "+r.Target[:min(12, len(r.Target))]))
+		b.WriteString("\n")
+		for _, msg := range r.BeyondBreaking {
+			b.WriteString(bodySnippetStyle.Render("    • " + truncate(msg.Message, m.width-8)))
+			b.WriteString("\n")
+		}
+	}
+
+	// Releases, with their changelog entry in place of the body when the
+	// repository keeps a changelog
+	shown := make(map[int]bool)
 	if len(r.Releases) > 0 {
 		b.WriteString("\n")
 		b.WriteString("  " + detailSectionStyle.Render("📦 Recent Releases"))
@@ -473,8 +841,17 @@ func (m Model) viewDetailView() string {
 			if rel.Name != "" && rel.Name != rel.Tag {
 				name = " — " + rel.Name
 			}
+			if rel.BeyondRange {
+				name += " (out of range)"
+			}
 			b.WriteString(fmt.Sprintf("    %s %s%s\n", icon, tag, name))
 
+			if i := changelogIndex(r.Changelog, rel.Tag); i >= 0 {
+				shown[i] = true
+				b.WriteString(m.changelogItems(r.Changelog[i]))
+				continue
+			}
+
 			// Show first 3 lines of body
 			if rel.Body != "" {
 				lines := strings.Split(rel.Body, "\n")
@@ -494,6 +871,28 @@ func (m Model) viewDetailView() string {
 		}
 	}
 
+	// Changelog entries without a release, and unreleased changes, which
+	// do not count toward the severity
+	if len(shown) < len(r.Changelog) || r.Unreleased != nil || r.ChangelogNote != "" {
+		b.WriteString("\n")
+		b.WriteString("  " + detailSectionStyle.Render("📜 Changelog"))
+		b.WriteString("\n")
+		for i, s := range r.Changelog {
+			if shown[i] {
+				continue
+			}
+			b.WriteString("    " + releaseTagStyle.Render(s.Heading) + "\n")
+			b.WriteString(m.changelogItems(s))
+		}
+		if s := r.Unreleased; s != nil {
+			b.WriteString("    " + releaseTagStyle.Render(s.Heading+" (not released yet)") + "\n")
+			b.WriteString(m.changelogItems(*s))
+		}
+		if r.ChangelogNote != "" {
+			b.WriteString(bodySnippetStyle.Render(r.ChangelogNote) + "\n")
+		}
+	}
+
 	// Help
 	b.WriteString("\n")
 	help := "  esc/q back  •  j/k scroll"
@@ -502,6 +901,92 @@ func (m Model) viewDetailView() string {
 	return b.String()
 }
 
+// changelogIndex returns the changelog section for a release tag, or -1.
+func changelogIndex(sections []detector.ChangelogSection, tag string) int {
+	v, ok := semver.Parse(tag)
+	if !ok {
+		return -1
+	}
+	for i, s := range sections {
+		if sv, ok := semver.Parse(s.Version); ok && sv.Compare(v) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// changelogItems renders the breaking changes and deprecations a
+// changelog section lists.
+func (m Model) changelogItems(s detector.ChangelogSection) string {
+	if len(s.Breaking)+len(s.Deprecations) == 0 {
+		return bodySnippetStyle.Render("no breaking changes listed") + "\n"
+	}
+	var b strings.Builder
+	for _, item := range s.Breaking {
+		b.WriteString(bodySnippetStyle.Render(breakingStyle.Render("⚠ " + truncate(item, m.width-12))))
+		b.WriteString("\n")
+	}
+	for _, item := range s.Deprecations {
+		b.WriteString(bodySnippetStyle.Render(deprecStyle.Render("⌛ " + truncate(item, m.width-12))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// highlightMatch renders a finding's text with the matched words
+// emphasized, cut to about width characters around the match.
+func highlightMatch(f detector.Finding, width int) string {
+	text, start, end := f.Text, f.Start, f.End
+	if f.Match() == "" {
+		return truncate(text, width)
+	}
+	before, match, after := []rune(text[:start]), text[start:end], []rune(text[end:])
+	room := max(width-len([]rune(match)), 0)
+	if len(before) > room/2 {
+		keep := max(room/2, room-len(after))
+		if keep < len(before) {
+			before = append([]rune("…"), before[len(before)-keep:]...)
+		}
+	}
+	if left := room - len(before); len(after) > left {
+		after = append(after[:max(left-1, 0)], '…')
+	}
+	return string(before) + matchStyle.Render(match) + string(after)
+}
+
+// shortRef abbreviates commit SHAs and leaves tags alone.
+func shortRef(ref string) string {
+	if len(ref) == 40 {
+		return ref[:10]
+	}
+	return ref
+}
+
+// commitDetail renders how a flagged commit was classified: its
+// Conventional Commits type and scope, what flagged it, and the
+// BREAKING CHANGE note if there is one.
+func (m Model) commitDetail(msg detector.CommitMessage) string {
+	var parts []string
+	if msg.Type != "" {
+		kind := msg.Type
+		if msg.Scope != "" {
+			kind += "(" + msg.Scope + ")"
+		}
+		parts = append(parts, kind)
+	}
+	if msg.Signal != "" {
+		parts = append(parts, msg.Signal)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	out := commitKindStyle.Render(strings.Join(parts, " · ")) + "\n"
+	if msg.Note != "" {
+		out += bodySnippetStyle.Render("  "+truncate(msg.Note, m.width-10)) + "\n"
+	}
+	return out
+}
+
 // severityLabel returns a styled severity label.
 func severityLabel(s detector.Severity) string {
 	switch s {
@@ -516,6 +1001,31 @@ func severityLabel(s detector.Severity) string {
 	}
 }
 
+func healthLabel(h detector.HealthStatus) string {
+	switch h {
+	case detector.HealthNotFound:
+		return errorStyle.Render(h.Icon() + " not found")
+	case detector.HealthArchived:
+		return deprecStyle.Render(h.Icon() + " archived")
+	case detector.HealthStale:
+		return lipgloss.NewStyle().Foreground(colorDim).Render(h.Icon() + " stale")
+	default:
+		return ""
+	}
+}
+
+// healthDetail describes the maintenance signals for the detail view.
+func healthDetail(h detector.Health) string {
+	var parts []string
+	if h.Status != detector.HealthOK {
+		parts = append(parts, healthLabel(h.Status))
+	}
+	if !h.LastPush.IsZero() {
+		parts = append(parts, fmt.Sprintf("last push %d days ago", h.DaysSincePush))
+	}
+	return strings.Join(parts, "  •  ")
+}
+
 // truncate shortens a string to maxLen.
 func truncate(s string, maxLen int) string {
 	if maxLen <= 0 {